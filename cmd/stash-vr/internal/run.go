@@ -8,14 +8,13 @@ import (
 	"stash-vr/internal/sections"
 	"stash-vr/internal/server"
 	"stash-vr/internal/stash"
-	"stash-vr/internal/stash/gql"
+	"stash-vr/internal/stash/compat"
+	"stash-vr/internal/watcher"
 
 	"github.com/Khan/genqlient/graphql"
 	"github.com/rs/zerolog/log"
 )
 
-const listenAddress = "0.0.0.0:9664"
-
 func Run() error {
 	ctx := application.InterruptableContext()
 
@@ -27,7 +26,12 @@ func Run() error {
 
 	sections.Get(ctx, stashClient)
 
-	err := server.Listen(ctx, listenAddress, stashClient)
+	go watcher.Run(ctx, stashClient, func(ctx context.Context) {
+		stash.InvalidateAll()
+		sections.Refresh(ctx, stashClient)
+	})
+
+	err := server.Listen(ctx, stashClient)
 	if err != nil {
 		return fmt.Errorf("server: %w", err)
 	}
@@ -38,9 +42,12 @@ func Run() error {
 func logVersions(ctx context.Context, client graphql.Client) {
 	log.Info().Str("Stash-VR version", application.BuildVersion).Send()
 
-	if version, err := gql.Version(ctx, client); err != nil {
-		log.Warn().Err(err).Msg("Failed to retrieve stash version")
+	if dialect, err := compat.Detect(ctx, client); err != nil {
+		log.Warn().Err(err).Msg("Failed to detect stash version")
 	} else {
-		log.Info().Str("Stash version", version.Version.Version).Send()
+		log.Info().Str("Stash version", dialect.Version.String()).Send()
+		if !dialect.Version.AtLeast(compat.MinimumVersion) {
+			log.Warn().Str("Stash version", dialect.Version.String()).Str("minimum", compat.MinimumVersion.String()).Msg("Stash version not supported, upgrade Stash")
+		}
 	}
 }
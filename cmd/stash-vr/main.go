@@ -4,12 +4,17 @@ package main
 
 import (
 	"stash-vr/cmd/stash-vr/internal"
-	_ "stash-vr/internal/logger"
+	"stash-vr/internal/config"
+	"stash-vr/internal/logger"
+	"stash-vr/internal/sections/definition"
 
 	"github.com/rs/zerolog/log"
 )
 
 func main() {
+	config.SetFiltersValidator(definition.ValidateFilters)
+	logger.Init()
+
 	if err := internal.Run(); err != nil {
 		log.Warn().Err(err).Msg("Application EXIT with ERROR")
 	} else {
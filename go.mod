@@ -5,9 +5,11 @@ go 1.19
 require (
 	github.com/Khan/genqlient v0.5.0
 	github.com/go-chi/chi/v5 v5.0.7
+	github.com/gorilla/websocket v1.5.0
 	github.com/rs/zerolog v1.28.0
 	golang.org/x/image v0.0.0-20220902085622-e7cb96979f69
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
@@ -21,5 +23,4 @@ require (
 	golang.org/x/sys v0.0.0-20220319134239-a9b59b0215f8 // indirect
 	golang.org/x/tools v0.1.10 // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
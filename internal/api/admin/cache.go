@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"context"
+	"stash-vr/internal/cache"
+	"stash-vr/internal/sections"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+type cacheDoc struct {
+	SectionsBuiltAt time.Time             `json:"sectionsBuiltAt"`
+	Sections        []sectionStats        `json:"sections"`
+	Caches          []cache.Stats         `json:"caches"`
+	Discover        sections.DiscoverSeed `json:"discover"`
+}
+
+type sectionStats struct {
+	Name             string    `json:"name"`
+	FilterId         string    `json:"filterId,omitempty"`
+	BuiltAt          time.Time `json:"builtAt"`
+	FilterDurationMs int64     `json:"filterDurationMs"`
+	Scenes           int       `json:"scenes"`
+}
+
+func buildCache(ctx context.Context, client graphql.Client) cacheDoc {
+	ss := sections.Get(ctx, client)
+	doc := cacheDoc{
+		SectionsBuiltAt: sections.UpdatedAt(ctx),
+		Sections:        make([]sectionStats, len(ss)),
+		Caches:          cache.All(),
+		Discover:        sections.CurrentDiscoverSeed(),
+	}
+	for i, s := range ss {
+		doc.Sections[i] = sectionStats{
+			Name:             s.Name,
+			FilterId:         s.FilterId,
+			BuiltAt:          s.BuiltAt,
+			FilterDurationMs: s.FilterDuration.Milliseconds(),
+			Scenes:           s.Len(),
+		}
+	}
+	return doc
+}
@@ -0,0 +1,28 @@
+package admin
+
+import (
+	"stash-vr/internal/config"
+	"stash-vr/internal/sections/diagnostic"
+)
+
+type diagnosticsDoc struct {
+	Strict  bool               `json:"strict"`
+	Filters []filterDiagnostic `json:"filters"`
+}
+
+type filterDiagnostic struct {
+	diagnostic.Diagnostic
+	Status string `json:"status"`
+}
+
+func buildDiagnostics() diagnosticsDoc {
+	ds := diagnostic.All()
+	doc := diagnosticsDoc{
+		Strict:  config.Get().IsStrictFilters,
+		Filters: make([]filterDiagnostic, len(ds)),
+	}
+	for i, d := range ds {
+		doc.Filters[i] = filterDiagnostic{Diagnostic: d, Status: d.Status()}
+	}
+	return doc
+}
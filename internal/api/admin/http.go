@@ -0,0 +1,52 @@
+package admin
+
+import (
+	"github.com/Khan/genqlient/graphql"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"stash-vr/internal/api/internal"
+	"stash-vr/internal/sections"
+	"stash-vr/internal/stash"
+)
+
+type httpHandler struct {
+	Client graphql.Client
+}
+
+func (h *httpHandler) cacheHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	data := buildCache(ctx, h.Client)
+	if err := internal.WriteJson(ctx, w, data); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("write")
+	}
+}
+
+// cacheRefreshHandler drops cached scene data and rebuilds all sections before responding with the new state.
+func (h *httpHandler) cacheRefreshHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	log.Ctx(ctx).Info().Msg("Cache refresh requested")
+	stash.InvalidateAll()
+	sections.Refresh(ctx, h.Client)
+
+	data := buildCache(ctx, h.Client)
+	if err := internal.WriteJson(ctx, w, data); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("write")
+	}
+}
+
+// discoverRotateHandler replaces the seed of the discover section and responds with the new one once sections are rebuilt.
+func (h *httpHandler) discoverRotateHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	seed := sections.RotateDiscover(ctx, h.Client)
+	if err := internal.WriteJson(ctx, w, seed); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("write")
+	}
+}
+
+func (h *httpHandler) diagnosticsHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	sections.Get(ctx, h.Client)
+	if err := internal.WriteJson(ctx, w, buildDiagnostics()); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("write")
+	}
+}
@@ -0,0 +1,18 @@
+package admin
+
+import (
+	"github.com/Khan/genqlient/graphql"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"stash-vr/internal/api/internal"
+)
+
+func Router(client graphql.Client) http.Handler {
+	httpHandler := httpHandler{Client: client}
+	r := chi.NewRouter()
+	r.Get("/cache", internal.LogRoute("cache", httpHandler.cacheHandler))
+	r.Post("/cache/refresh", internal.LogRoute("cacheRefresh", httpHandler.cacheRefreshHandler))
+	r.Get("/diagnostics", internal.LogRoute("diagnostics", httpHandler.diagnosticsHandler))
+	r.Post("/discover/rotate", internal.LogRoute("discoverRotate", httpHandler.discoverRotateHandler))
+	return r
+}
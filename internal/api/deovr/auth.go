@@ -0,0 +1,36 @@
+package deovr
+
+import (
+	"net/http"
+	"stash-vr/internal/auth"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	authorizedBadLogin = "-1"
+	authorizedGuest    = "0"
+	authorizedMember   = "1"
+)
+
+// getAuthorized checks the login and password form fields DeoVR posts along with every request to a site requiring login.
+func getAuthorized(req *http.Request) (string, *http.Request) {
+	if !auth.IsEnabled() {
+		return authorizedMember, req
+	}
+
+	ctx := req.Context()
+	if err := req.ParseForm(); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("form: parse")
+	}
+
+	login := req.PostFormValue("login")
+	if login == "" {
+		return authorizedGuest, req
+	}
+	if !auth.Authenticate(login, req.PostFormValue("password")) {
+		log.Ctx(ctx).Warn().Str("username", login).Msg("Failed login attempt")
+		return authorizedBadLogin, req
+	}
+	return authorizedMember, req.WithContext(auth.WithUsername(ctx, login))
+}
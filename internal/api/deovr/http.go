@@ -6,6 +6,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"net/http"
 	"stash-vr/internal/api/internal"
+	"stash-vr/internal/util"
 )
 
 type httpHandler struct {
@@ -13,10 +14,16 @@ type httpHandler struct {
 }
 
 func (h httpHandler) indexHandler(w http.ResponseWriter, req *http.Request) {
+	authorized, req := getAuthorized(req)
 	ctx := req.Context()
-	baseUrl := internal.GetBaseUrl(req)
+	baseUrl := util.GetBaseUrl(req)
 
-	data := buildIndex(ctx, h.Client, baseUrl)
+	data := index{Authorized: authorized, Scenes: []scene{}}
+	if authorized == authorizedMember {
+		data = buildIndex(ctx, h.Client, baseUrl)
+	} else {
+		log.Ctx(ctx).Debug().Str("authorized", authorized).Msg("Access denied")
+	}
 
 	if err := internal.WriteJson(ctx, w, data); err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("write")
@@ -25,12 +32,52 @@ func (h httpHandler) indexHandler(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+func (h httpHandler) adHocHandler(w http.ResponseWriter, req *http.Request) {
+	authorized, req := getAuthorized(req)
+	ctx := req.Context()
+	baseUrl := util.GetBaseUrl(req)
+
+	if authorized != authorizedMember {
+		log.Ctx(ctx).Debug().Str("authorized", authorized).Msg("Access denied")
+		if err := internal.WriteJson(ctx, w, index{Authorized: authorized, Scenes: []scene{}}); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("write")
+		}
+		return
+	}
+
+	r, err := internal.AdHocRequest(req)
+	if err != nil {
+		internal.WriteAdHocError(ctx, w, err)
+		return
+	}
+	ctx = internal.AdHocLogContext(ctx, r)
+
+	data, err := buildAdHocIndex(ctx, h.Client, baseUrl, r)
+	if err != nil {
+		internal.WriteAdHocError(ctx, w, err)
+		return
+	}
+	if err := internal.WriteJson(ctx, w, data); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("write")
+	}
+}
+
 func (h httpHandler) videoDataHandler(w http.ResponseWriter, req *http.Request) {
+	authorized, req := getAuthorized(req)
 	ctx := req.Context()
-	baseUrl := internal.GetBaseUrl(req)
+	baseUrl := util.GetBaseUrl(req)
 	sceneId := chi.URLParam(req, "videoId")
+	markerId := chi.URLParam(req, "markerId")
+
+	if authorized != authorizedMember {
+		log.Ctx(ctx).Debug().Str("authorized", authorized).Msg("Access denied")
+		if err := internal.WriteJson(ctx, w, videoData{Authorized: authorized}); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("write")
+		}
+		return
+	}
 
-	data, err := buildVideoData(ctx, h.Client, baseUrl, sceneId)
+	data, err := buildVideoData(ctx, h.Client, baseUrl, sceneId, markerId)
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("build")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/Khan/genqlient/graphql"
 	"stash-vr/internal/sections"
+	"stash-vr/internal/sections/definition"
 	"stash-vr/internal/sections/section"
 	"stash-vr/internal/stash"
 	"stash-vr/internal/util"
@@ -28,15 +29,24 @@ type previewData struct {
 }
 
 func buildIndex(ctx context.Context, client graphql.Client, baseUrl string) index {
-	ss := sections.Get(ctx, client)
+	ss := section.ForPlayer(definition.PlayerDeoVR, sections.Get(ctx, client))
 
 	scenes := fromSections(baseUrl, ss)
 
-	index := index{Authorized: "1", Scenes: scenes}
+	index := index{Authorized: authorizedMember, Scenes: scenes}
 
 	return index
 }
 
+// buildAdHocIndex is the one-off library of an ad-hoc request, the configured sections are left out.
+func buildAdHocIndex(ctx context.Context, client graphql.Client, baseUrl string, r sections.AdHocRequest) (index, error) {
+	ss, err := sections.AdHoc(ctx, client, r)
+	if err != nil {
+		return index{}, err
+	}
+	return index{Authorized: authorizedMember, Scenes: fromSections(baseUrl, ss)}, nil
+}
+
 func fromSections(baseUrl string, sections []section.Section) []scene {
 	return util.Transform[section.Section, scene](func(section section.Section) (scene, error) {
 		return fromSection(baseUrl, section), nil
@@ -46,16 +56,25 @@ func fromSections(baseUrl string, sections []section.Section) []scene {
 func fromSection(baseUrl string, section section.Section) scene {
 	s := scene{
 		Name: section.Name,
-		List: make([]previewData, len(section.PreviewPartsList)),
+		List: make([]previewData, 0, section.Len()),
 	}
-	for i, p := range section.PreviewPartsList {
-		s.List[i] = previewData{
+	for _, p := range section.PreviewPartsList {
+		s.List = append(s.List, previewData{
 			Id:           p.Id,
 			ThumbnailUrl: stash.ApiKeyed(p.Paths.Screenshot),
 			Title:        p.Title,
 			VideoLength:  int(p.Files[0].Duration),
 			VideoUrl:     getVideoDataUrl(baseUrl, p.Id),
-		}
+		})
+	}
+	for _, m := range section.Markers {
+		s.List = append(s.List, previewData{
+			Id:           m.Scene.Id,
+			ThumbnailUrl: stash.ApiKeyed(m.Screenshot),
+			Title:        m.Title,
+			VideoLength:  int(m.Scene.Files[0].Duration),
+			VideoUrl:     getMarkerVideoDataUrl(baseUrl, m.Scene.Id, m.Id),
+		})
 	}
 	return s
 }
@@ -12,10 +12,23 @@ func Router(client graphql.Client) http.Handler {
 	r := chi.NewRouter()
 
 	r.Get("/", internal.LogRoute("index", httpHandler.indexHandler))
+	r.Post("/", internal.LogRoute("index", httpHandler.indexHandler))
+	r.Get("/q", internal.LogRoute("adHoc", httpHandler.adHocHandler))
+	r.Post("/q", internal.LogRoute("adHoc", httpHandler.adHocHandler))
+	r.Get("/q/*", internal.LogRoute("adHoc", httpHandler.adHocHandler))
+	r.Post("/q/*", internal.LogRoute("adHoc", httpHandler.adHocHandler))
 	r.Get("/{videoId}", internal.LogRoute("videoData", internal.LogVideoId(httpHandler.videoDataHandler)))
+	r.Post("/{videoId}", internal.LogRoute("videoData", internal.LogVideoId(httpHandler.videoDataHandler)))
+	r.Get("/{videoId}/marker/{markerId}", internal.LogRoute("markerVideoData", internal.LogVideoId(httpHandler.videoDataHandler)))
+	r.Post("/{videoId}/marker/{markerId}", internal.LogRoute("markerVideoData", internal.LogVideoId(httpHandler.videoDataHandler)))
 	return r
 }
 
 func getVideoDataUrl(baseUrl string, id string) string {
 	return baseUrl + "/deovr/" + id
 }
+
+// getMarkerVideoDataUrl is the video data of scene id starting at marker markerId.
+func getMarkerVideoDataUrl(baseUrl string, id string, markerId string) string {
+	return getVideoDataUrl(baseUrl, id) + "/marker/" + markerId
+}
@@ -7,7 +7,9 @@ import (
 	"path/filepath"
 	"regexp"
 	"stash-vr/internal/api/heatmap"
+	"stash-vr/internal/api/internal"
 	"stash-vr/internal/config"
+	"stash-vr/internal/sections/section"
 	"stash-vr/internal/stash"
 	"stash-vr/internal/stash/gql"
 	"strings"
@@ -61,8 +63,9 @@ type videoSource struct {
 	Url        string `json:"url"`
 }
 
-func buildVideoData(ctx context.Context, client graphql.Client, baseUrl string, sceneId string) (videoData, error) {
-	findSceneResponse, err := gql.FindSceneFull(ctx, client, sceneId)
+// buildVideoData builds the video data of a scene, or of a marker entry if markerId is set.
+func buildVideoData(ctx context.Context, client graphql.Client, baseUrl string, sceneId string, markerId string) (videoData, error) {
+	findSceneResponse, err := stash.FindSceneFull(ctx, client, sceneId)
 	if err != nil {
 		return videoData{}, fmt.Errorf("FindScene: %w", err)
 	}
@@ -86,7 +89,7 @@ func buildVideoData(ctx context.Context, client graphql.Client, baseUrl string,
 	}
 
 	vd := videoData{
-		Authorized:   "1",
+		Authorized:   authorizedMember,
 		FullAccess:   true,
 		Title:        title,
 		Id:           s.Id,
@@ -101,9 +104,26 @@ func buildVideoData(ctx context.Context, client graphql.Client, baseUrl string,
 	setMarkers(s, &vd)
 	set3DFormat(s, &vd)
 
+	if markerId != "" {
+		if err := setMarker(s, markerId, &vd); err != nil {
+			return videoData{}, err
+		}
+	}
 	return vd, nil
 }
 
+// setMarker titles and thumbnails videoData as the marker and skips to it.
+func setMarker(s gql.SceneFullParts, markerId string, videoData *videoData) error {
+	sm, err := internal.FindMarker(s.SceneScanParts, markerId)
+	if err != nil {
+		return err
+	}
+	videoData.Title = section.MarkerTitle(sm.Primary_tag.Name, sm.Title)
+	videoData.ThumbnailUrl = stash.ApiKeyed(sm.Screenshot)
+	videoData.SkipIntro = int(sm.Seconds)
+	return nil
+}
+
 func setChromaKey(findSceneResponse *gql.FindSceneFullResponse, data *videoData) {
 	tagName := config.Get().PassThroughTag
 
@@ -1,46 +1,59 @@
 package heatmap
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"github.com/Khan/genqlient/graphql"
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
 	"image/jpeg"
 	"net/http"
 	"stash-vr/internal/api/internal"
+	"stash-vr/internal/auth"
+	"stash-vr/internal/cache"
 	"stash-vr/internal/stash"
 	"stash-vr/internal/stash/gql"
+	"time"
 )
 
+var (
+	errSceneNotFound = errors.New("scene not found")
+	errStash         = errors.New("stash")
+)
+
+// coverCache holds encoded heatmap covers, they are expensive to build and requested for every scene in the index.
+var coverCache = cache.New[string, []byte]("heatmap covers", cache.Options{TTL: time.Hour, MaxEntries: 500})
+
 func CoverHandler(client graphql.Client) http.HandlerFunc {
 	f := func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		sceneId := chi.URLParam(r, "videoId")
 
-		response, err := gql.FindScriptDataBySceneId(ctx, client, sceneId)
-		if err != nil {
-			log.Ctx(ctx).Err(err).Msg("FindScriptDataBySceneId")
-			w.WriteHeader(http.StatusBadGateway)
+		if auth.IsEnabled() && !auth.VerifySignature(coverResource(sceneId), r.URL.Query().Get("sig")) {
+			log.Ctx(ctx).Debug().Msg("Invalid cover signature")
+			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
-		if response.FindScene == nil {
-			log.Ctx(ctx).Debug().Msg("Scene not found")
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
-		p := response.FindScene.Paths
-		cover, err := buildHeatmapCover(ctx, stash.ApiKeyed(p.Screenshot), stash.ApiKeyed(p.Interactive_heatmap))
+
+		b, err := coverCache.Get(ctx, sceneId, func(ctx context.Context) ([]byte, error) {
+			return buildCover(ctx, client, sceneId)
+		})
 		if err != nil {
-			log.Ctx(ctx).Err(err).Msg("buildHeatmapCover")
-			if errors.Is(err, errImageNotFound) {
+			log.Ctx(ctx).Err(err).Msg("cover")
+			switch {
+			case errors.Is(err, errSceneNotFound), errors.Is(err, errImageNotFound):
 				w.WriteHeader(http.StatusNotFound)
-			} else {
+			case errors.Is(err, errStash):
+				w.WriteHeader(http.StatusBadGateway)
+			default:
 				w.WriteHeader(http.StatusInternalServerError)
 			}
 			return
 		}
-		err = jpeg.Encode(w, cover, nil)
-		if err != nil {
+		w.Header().Set("Content-Type", "image/jpeg")
+		if _, err := w.Write(b); err != nil {
 			log.Ctx(ctx).Err(err).Msg("cover: write")
 			return
 		}
@@ -48,6 +61,35 @@ func CoverHandler(client graphql.Client) http.HandlerFunc {
 	return internal.LogRoute("cover", internal.LogVideoId(f))
 }
 
+// GetCoverUrl returns the url of the heatmap cover, signed when auth is enabled since players fetch images without credentials.
 func GetCoverUrl(baseUrl string, sceneId string) string {
-	return baseUrl + "/cover/" + sceneId
+	coverUrl := baseUrl + "/cover/" + sceneId
+	if auth.IsEnabled() {
+		coverUrl += "?sig=" + auth.Sign(coverResource(sceneId))
+	}
+	return coverUrl
+}
+
+func buildCover(ctx context.Context, client graphql.Client, sceneId string) ([]byte, error) {
+	response, err := gql.FindScriptDataBySceneId(ctx, client, sceneId)
+	if err != nil {
+		return nil, fmt.Errorf("%w: FindScriptDataBySceneId: %v", errStash, err)
+	}
+	if response.FindScene == nil {
+		return nil, errSceneNotFound
+	}
+	p := response.FindScene.Paths
+	cover, err := buildHeatmapCover(ctx, stash.ApiKeyed(p.Screenshot), stash.ApiKeyed(p.Interactive_heatmap))
+	if err != nil {
+		return nil, fmt.Errorf("buildHeatmapCover: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, cover, nil); err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func coverResource(sceneId string) string {
+	return "cover/" + sceneId
 }
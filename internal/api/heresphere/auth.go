@@ -0,0 +1,80 @@
+package heresphere
+
+import (
+	"encoding/json"
+	"net/http"
+	"stash-vr/internal/api/internal"
+	"stash-vr/internal/auth"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	accessBadLogin = -1
+	accessGuest    = 0
+	accessMember   = 1
+)
+
+type authRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type authResponse struct {
+	AuthToken string `json:"auth-token"`
+	Access    int    `json:"access"`
+}
+
+type deniedResponse struct {
+	Access int `json:"access"`
+}
+
+func (h *httpHandler) authHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	var authReq authRequest
+	if err := json.NewDecoder(req.Body).Decode(&authReq); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("body: unmarshal")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response := authResponse{Access: accessBadLogin}
+	if !auth.IsEnabled() {
+		response.Access = accessMember
+	} else if auth.Authenticate(authReq.Username, authReq.Password) {
+		response = authResponse{AuthToken: auth.NewToken(authReq.Username), Access: accessMember}
+		log.Ctx(ctx).Info().Str("username", authReq.Username).Msg("Login")
+	} else {
+		log.Ctx(ctx).Warn().Str("username", authReq.Username).Msg("Failed login attempt")
+	}
+
+	if err := internal.WriteJson(ctx, w, response); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("write")
+	}
+}
+
+// requireAccess answers requests without a valid auth-token with the access level HereSphere uses to show its login prompt.
+func requireAccess(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if !auth.IsEnabled() {
+			next(w, req)
+			return
+		}
+
+		ctx := req.Context()
+		access := accessGuest
+		if token := req.Header.Get("auth-token"); token != "" {
+			access = accessBadLogin
+			if username, ok := auth.VerifyToken(token); ok {
+				next(w, req.WithContext(auth.WithUsername(ctx, username)))
+				return
+			}
+		}
+
+		log.Ctx(ctx).Debug().Int("access", access).Msg("Access denied")
+		if err := internal.WriteJson(ctx, w, deniedResponse{Access: access}); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("write")
+		}
+	}
+}
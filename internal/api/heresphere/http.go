@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"stash-vr/internal/api/internal"
 	"stash-vr/internal/config"
+	"stash-vr/internal/stash"
+	"stash-vr/internal/util"
 )
 
 type httpHandler struct {
@@ -17,7 +19,7 @@ type httpHandler struct {
 
 func (h *httpHandler) indexHandler(w http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
-	baseUrl := internal.GetBaseUrl(req)
+	baseUrl := util.GetBaseUrl(req)
 
 	data := buildIndex(ctx, h.Client, baseUrl)
 
@@ -26,9 +28,35 @@ func (h *httpHandler) indexHandler(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+func (h *httpHandler) adHocHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	baseUrl := util.GetBaseUrl(req)
+
+	r, err := internal.AdHocRequest(req)
+	if err != nil {
+		internal.WriteAdHocError(ctx, w, err)
+		return
+	}
+	ctx = internal.AdHocLogContext(ctx, r)
+
+	var data any
+	if internal.IsAdHocScan(req) {
+		data, err = buildAdHocScan(ctx, h.Client, baseUrl, r)
+	} else {
+		data, err = buildAdHocIndex(ctx, h.Client, baseUrl, r)
+	}
+	if err != nil {
+		internal.WriteAdHocError(ctx, w, err)
+		return
+	}
+	if err := internal.WriteJson(ctx, w, data); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("write")
+	}
+}
+
 func (h *httpHandler) scanHandler(w http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
-	baseUrl := internal.GetBaseUrl(req)
+	baseUrl := util.GetBaseUrl(req)
 
 	data, err := buildScan(ctx, h.Client, baseUrl)
 	if err != nil {
@@ -45,8 +73,9 @@ func (h *httpHandler) videoDataHandler(w http.ResponseWriter, req *http.Request)
 	defer req.Body.Close()
 
 	ctx := req.Context()
-	baseUrl := internal.GetBaseUrl(req)
+	baseUrl := util.GetBaseUrl(req)
 	sceneId := chi.URLParam(req, "videoId")
+	markerId := chi.URLParam(req, "markerId")
 
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
@@ -62,14 +91,23 @@ func (h *httpHandler) videoDataHandler(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	// a marker entry plays its scene, edits and deletes of the whole scene are left to the scene's own entry
+	if markerId != "" && (vdReq.isUpdateRequest() || vdReq.isDeleteRequest()) {
+		log.Ctx(ctx).Warn().Str("markerId", markerId).Msg("Update or delete request of a marker entry refused")
+		http.Error(w, "update and delete requests aren't supported for markers, use the entry of the scene", http.StatusBadRequest)
+		return
+	}
+
 	if vdReq.isUpdateRequest() {
 		update(ctx, h.Client, sceneId, vdReq)
+		stash.InvalidateScene(sceneId)
 		w.WriteHeader(http.StatusOK)
 		return
 	}
 
 	if vdReq.isDeleteRequest() {
 		destroy(ctx, h.Client, sceneId)
+		stash.InvalidateScene(sceneId)
 		w.WriteHeader(http.StatusOK)
 		return
 	}
@@ -80,7 +118,7 @@ func (h *httpHandler) videoDataHandler(w http.ResponseWriter, req *http.Request)
 
 	var includeMediaSource = vdReq.NeedsMediaSource == nil || *vdReq.NeedsMediaSource
 
-	data, err := buildVideoData(ctx, h.Client, baseUrl, sceneId, includeMediaSource)
+	data, err := buildVideoData(ctx, h.Client, baseUrl, sceneId, markerId, includeMediaSource)
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("build")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -0,0 +1,158 @@
+package heresphere
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// fakeStash answers the saved filter "Top rated", the performer "Alice", two scenes for any scene filter and the scan
+// data of scenes by id. Anything else fails, such as the saved filters of the configured sections.
+type fakeStash struct{}
+
+func (fakeStash) MakeRequest(_ context.Context, req *graphql.Request, resp *graphql.Response) error {
+	var data string
+	switch req.OpName {
+	case "FindSavedFilters":
+		data = `{"findSavedFilters": [{"id": "1", "name": "Top rated", "mode": "SCENES", "find_filter": {"sort": "rating", "direction": "DESC"}, "object_filter": {}}]}`
+	case "FindPerformerByName":
+		data = `{"findPerformers": {"performers": []}}`
+		if b, _ := json.Marshal(req.Variables); strings.Contains(string(b), `"Alice"`) {
+			data = `{"findPerformers": {"performers": [{"id": "4"}]}}`
+		}
+	case "FindTagByName":
+		data = `{"findTags": {"tags": []}}`
+	case "FindScenePreviewsByFilter":
+		data = `{"findScenes": {"scenes": [{"id": "11"}, {"id": "12"}]}}`
+	case "FindSceneScansByIds":
+		var variables struct {
+			SceneIds []int `json:"scene_ids"`
+		}
+		b, _ := json.Marshal(req.Variables)
+		if err := json.Unmarshal(b, &variables); err != nil {
+			return err
+		}
+		sort.Ints(variables.SceneIds)
+		scenes := make([]string, len(variables.SceneIds))
+		for i, id := range variables.SceneIds {
+			scenes[i] = fmt.Sprintf(`{"id": "%d", "created_at": "2024-01-01T00:00:00Z", "files": [{"duration": 60}]}`, id)
+		}
+		data = `{"findScenes": {"scenes": [` + strings.Join(scenes, ",") + `]}}`
+	default:
+		return fmt.Errorf("unexpected request %s", req.OpName)
+	}
+	return json.Unmarshal([]byte(data), resp.Data)
+}
+
+func TestAdHocHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantNames  []string
+		wantBody   string
+	}{
+		{"escaped query path", "/q/performer:%22Alice%22", http.StatusOK, []string{`performer:"Alice"`}, ""},
+		{"escaped slash", "/q/title:%22a%2Fscan%22", http.StatusOK, []string{`title:"a/scan"`}, ""},
+		{"filter name path", "/q/Top%20rated", http.StatusOK, []string{"Top rated"}, ""},
+		{"filter parameter", "/q?filter=Top%20rated", http.StatusOK, []string{"Top rated"}, ""},
+		{"path over q", "/q/performer:Alice?q=frobnicate:x", http.StatusOK, []string{"performer:Alice"}, ""},
+		{"q parameter", "/q?q=performer:Alice", http.StatusOK, []string{"performer:Alice"}, ""},
+		{"missing filter", "/q?filter=Nope", http.StatusNotFound, nil, "Nope"},
+		{"parse error", "/q/performer:Alice%20frobnicate:x", http.StatusBadRequest, nil, "column 17"},
+		{"unknown performer", "/q/performer:Bob", http.StatusBadRequest, nil, "column"},
+		{"empty", "/q", http.StatusBadRequest, nil, "no query or filter"},
+	}
+	router := Router(fakeStash{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.target, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if !strings.Contains(w.Body.String(), tt.wantBody) {
+					t.Errorf("body = %q, want it to contain %q", w.Body, tt.wantBody)
+				}
+				return
+			}
+			var got index
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, l := range got.Library {
+				names = append(names, l.Name)
+				if len(l.List) != 2 {
+					t.Errorf("library %s has %d scenes, want 2", l.Name, len(l.List))
+				}
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("libraries = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestAdHocHandler_scan(t *testing.T) {
+	w := httptest.NewRecorder()
+	Router(fakeStash{}).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "http://stash-vr/q/performer:Alice/scan", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	var got scanDoc
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	var links []string
+	for _, e := range got.ScanData {
+		links = append(links, e.Link)
+	}
+	// the scenes of the library aren't in any configured section, those fail to build
+	want := []string{"http://stash-vr/heresphere/11", "http://stash-vr/heresphere/12"}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("scan data links = %v, want %v", links, want)
+	}
+}
+
+// recordingStash fails all requests and records their names.
+type recordingStash struct {
+	ops *[]string
+}
+
+func (r recordingStash) MakeRequest(_ context.Context, req *graphql.Request, _ *graphql.Response) error {
+	*r.ops = append(*r.ops, req.OpName)
+	return fmt.Errorf("unexpected request %s", req.OpName)
+}
+
+func TestVideoDataHandler_markerRefusesEdits(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"delete", `{"deleteFile": true}`},
+		{"update", `{"rating": 5}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ops []string
+			w := httptest.NewRecorder()
+			Router(recordingStash{ops: &ops}).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/5/marker/9", strings.NewReader(tt.body)))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+			if len(ops) > 0 {
+				t.Errorf("requests to Stash = %v, want none", ops)
+			}
+		})
+	}
+}
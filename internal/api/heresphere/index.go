@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/Khan/genqlient/graphql"
 	"stash-vr/internal/sections"
+	"stash-vr/internal/sections/definition"
 	"stash-vr/internal/sections/section"
 	"stash-vr/internal/util"
 )
@@ -19,13 +20,22 @@ type library struct {
 }
 
 func buildIndex(ctx context.Context, client graphql.Client, baseUrl string) index {
-	ss := sections.Get(ctx, client)
+	ss := section.ForPlayer(definition.PlayerHereSphere, sections.Get(ctx, client))
 
-	index := index{Access: 1, Library: fromSections(baseUrl, ss)}
+	index := index{Access: accessMember, Library: fromSections(baseUrl, ss)}
 
 	return index
 }
 
+// buildAdHocIndex is the one-off library of an ad-hoc request, the configured sections are left out.
+func buildAdHocIndex(ctx context.Context, client graphql.Client, baseUrl string, r sections.AdHocRequest) (index, error) {
+	ss, err := sections.AdHoc(ctx, client, r)
+	if err != nil {
+		return index{}, err
+	}
+	return index{Access: accessMember, Library: fromSections(baseUrl, ss)}, nil
+}
+
 func fromSections(baseUrl string, sections []section.Section) []library {
 	return util.Transform[section.Section, library](func(section section.Section) (library, error) {
 		return fromSection(baseUrl, section), nil
@@ -33,9 +43,12 @@ func fromSections(baseUrl string, sections []section.Section) []library {
 }
 
 func fromSection(baseUrl string, section section.Section) library {
-	o := library{Name: section.Name, List: make([]string, len(section.PreviewPartsList))}
-	for i, p := range section.PreviewPartsList {
-		o.List[i] = getVideoDataUrl(baseUrl, p.Id)
+	o := library{Name: section.Name, List: make([]string, 0, section.Len())}
+	for _, p := range section.PreviewPartsList {
+		o.List = append(o.List, getVideoDataUrl(baseUrl, p.Id))
+	}
+	for _, m := range section.Markers {
+		o.List = append(o.List, getMarkerVideoDataUrl(baseUrl, m.Scene.Id, m.Id))
 	}
 	return o
 }
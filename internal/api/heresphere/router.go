@@ -12,12 +12,21 @@ func Router(client graphql.Client) http.Handler {
 	httpHandler := httpHandler{Client: client}
 	r := chi.NewRouter()
 	r.Use(middleware.SetHeader("HereSphere-JSON-Version", "1"))
-	r.Post("/", internal.LogRoute("index", httpHandler.indexHandler))
-	r.Post("/scan", internal.LogRoute("scan", httpHandler.scanHandler))
-	r.Post("/{videoId}", internal.LogRoute("videoData", internal.LogVideoId(httpHandler.videoDataHandler)))
+	r.Post("/auth", internal.LogRoute("auth", httpHandler.authHandler))
+	r.Post("/", internal.LogRoute("index", requireAccess(httpHandler.indexHandler)))
+	r.Post("/q", internal.LogRoute("adHoc", requireAccess(httpHandler.adHocHandler)))
+	r.Post("/q/*", internal.LogRoute("adHoc", requireAccess(httpHandler.adHocHandler)))
+	r.Post("/scan", internal.LogRoute("scan", requireAccess(httpHandler.scanHandler)))
+	r.Post("/{videoId}", internal.LogRoute("videoData", internal.LogVideoId(requireAccess(httpHandler.videoDataHandler))))
+	r.Post("/{videoId}/marker/{markerId}", internal.LogRoute("markerVideoData", internal.LogVideoId(requireAccess(httpHandler.videoDataHandler))))
 	return r
 }
 
 func getVideoDataUrl(baseUrl string, id string) string {
 	return baseUrl + "/heresphere/" + id
 }
+
+// getMarkerVideoDataUrl is the video data of scene id starting at marker markerId.
+func getMarkerVideoDataUrl(baseUrl string, id string, markerId string) string {
+	return getVideoDataUrl(baseUrl, id) + "/marker/" + markerId
+}
@@ -3,10 +3,10 @@ package heresphere
 import (
 	"context"
 	"fmt"
+	"stash-vr/internal/profile"
 	"stash-vr/internal/sections"
 	"stash-vr/internal/stash/gql"
 	"stash-vr/internal/util"
-	"strconv"
 
 	"github.com/Khan/genqlient/graphql"
 )
@@ -28,36 +28,37 @@ type scanDataElement struct {
 }
 
 func buildScan(ctx context.Context, client graphql.Client, baseUrl string) (scanDoc, error) {
-	ss := sections.Get(ctx, client)
-	sceneIdMap := make(map[int]any)
-	for _, s := range ss {
-		for _, preview := range s.PreviewPartsList {
-			id, _ := strconv.Atoi(preview.Id)
-			sceneIdMap[id] = struct{}{}
-		}
-	}
-	sceneIds := make([]int, 0, len(sceneIdMap))
-	for id := range sceneIdMap {
-		sceneIds = append(sceneIds, id)
-	}
-	response, err := gql.FindSceneScansByIds(ctx, client, sceneIds)
+	return fromScans(ctx, baseUrl, sections.Scans(ctx, client)), nil
+}
+
+// buildAdHocScan is the scan data of the scenes in a one-off library.
+func buildAdHocScan(ctx context.Context, client graphql.Client, baseUrl string, r sections.AdHocRequest) (scanDoc, error) {
+	scans, err := sections.AdHocScans(ctx, client, r)
 	if err != nil {
-		return scanDoc{}, fmt.Errorf("FindSceneScansByIds: %w", err)
+		return scanDoc{}, err
 	}
+	return fromScans(ctx, baseUrl, scans), nil
+}
+
+func fromScans(ctx context.Context, baseUrl string, scans []*gql.SceneScanParts) scanDoc {
+	p := profile.FromContext(ctx)
 
-	sceneScans := util.Transform[*gql.FindSceneScansByIdsFindScenesFindScenesResultTypeScenesScene, scanDataElement](
-		func(part *gql.FindSceneScansByIdsFindScenesFindScenesResultTypeScenesScene) (scanDataElement, error) {
+	sceneScans := util.Transform[*gql.SceneScanParts, scanDataElement](
+		func(part *gql.SceneScanParts) (scanDataElement, error) {
+			if len(part.Files) == 0 {
+				return scanDataElement{}, fmt.Errorf("scene %s has no files", part.Id)
+			}
 			return scanDataElement{
 				Link:         getVideoDataUrl(baseUrl, part.Id),
 				Title:        part.Title,
 				DateReleased: part.Date,
 				DateAdded:    part.Created_at.Format("2006-01-02"),
 				Duration:     part.Files[0].Duration,
-				Rating:       float32(part.Rating100) / 20.0,
+				Rating:       float32(getRating100(p, *part)) / 20.0,
 				Favorites:    part.O_counter,
-				IsFavorite:   ContainsFavoriteTag(part.TagPartsArray),
-				Tags:         getTags(part.SceneScanParts),
+				IsFavorite:   ContainsFavoriteTag(part.TagPartsArray, p.FavoriteTag),
+				Tags:         getTags(*part),
 			}, nil
-		}).Ordered(response.FindScenes.Scenes)
-	return scanDoc{ScanData: sceneScans}, nil
+		}).Ordered(scans)
+	return scanDoc{ScanData: sceneScans}
 }
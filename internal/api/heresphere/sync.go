@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"stash-vr/internal/api/internal"
 	"stash-vr/internal/config"
+	"stash-vr/internal/profile"
+	"stash-vr/internal/sections"
 	"stash-vr/internal/stash"
 	"stash-vr/internal/stash/gql"
 	"strings"
@@ -40,10 +42,6 @@ func update(ctx context.Context, client graphql.Client, sceneId string, updateRe
 		updateRating(ctx, client, sceneId, *updateReq.Rating)
 	}
 
-	if updateReq.IsFavorite != nil {
-		updateFavorite(ctx, client, sceneId, *updateReq.IsFavorite)
-	}
-
 	if updateReq.Tags != nil {
 		details := parseUpdateRequestTags(ctx, client, *updateReq.Tags)
 
@@ -61,9 +59,24 @@ func update(ctx context.Context, client graphql.Client, sceneId string, updateRe
 
 		setMarkers(ctx, client, sceneId, details.markers)
 	}
+
+	if updateReq.IsFavorite != nil {
+		updateFavorite(ctx, client, sceneId, *updateReq.IsFavorite)
+	}
 }
 
 func updateTags(ctx context.Context, client graphql.Client, sceneId string, tagIds []string) {
+	// favorite tags are hidden from HereSphere and must survive the update
+	if response, err := gql.FindSceneTags(ctx, client, sceneId); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("updateTags: FindSceneTags")
+	} else if response.FindScene != nil {
+		for _, t := range response.FindScene.Tags {
+			if profile.IsFavoriteTag(t.Name) {
+				tagIds = append(tagIds, t.Id)
+			}
+		}
+	}
+
 	if _, err := gql.SceneUpdateTags(ctx, client, sceneId, tagIds); err != nil {
 		log.Ctx(ctx).Warn().Err(err).Interface("tagIds", tagIds).Msg("Failed to update tags")
 		return
@@ -104,12 +117,26 @@ func incrementO(ctx context.Context, client graphql.Client, sceneId string) {
 }
 
 func incrementPlayCount(ctx context.Context, client graphql.Client, sceneId string) {
-	response, err := gql.SceneIncrementPlayCount(ctx, client, sceneId)
-	if err != nil {
-		log.Ctx(ctx).Warn().Err(err).Msg("Failed to increment play count")
-		return
+	p := profile.FromContext(ctx)
+	if p.IsLocal {
+		playCount, err := p.IncrementPlayCount(sceneId)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("Failed to store play count")
+		} else {
+			log.Ctx(ctx).Debug().Int("Play Count", playCount).Str("profile", p.Username).Msg("Stored play count")
+		}
+	}
+	if p.WritesToStash() {
+		response, err := gql.SceneIncrementPlayCount(ctx, client, sceneId)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("Failed to increment play count")
+			return
+		}
+		log.Ctx(ctx).Debug().Interface("Play Count", response.SceneIncrementPlayCount).Msg("Incremented play count")
+		stash.InvalidateScene(sceneId)
+		// Play history sections show Stash's global history, shared by all profiles
+		sections.RefreshPlayHistory(ctx, client)
 	}
-	log.Ctx(ctx).Debug().Interface("Play Count", response.SceneIncrementPlayCount).Msg("Incremented play count")
 }
 
 func toggleOrganized(ctx context.Context, client graphql.Client, sceneId string) {
@@ -123,6 +150,19 @@ func toggleOrganized(ctx context.Context, client graphql.Client, sceneId string)
 
 func updateRating(ctx context.Context, client graphql.Client, sceneId string, rating float32) {
 	var newRating int = int(rating*20 + 0.5)
+
+	p := profile.FromContext(ctx)
+	if p.IsLocal {
+		if err := p.SetRating(sceneId, newRating); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Int("rating", newRating).Msg("Failed to store rating")
+		} else {
+			log.Ctx(ctx).Debug().Int("rating", newRating).Str("profile", p.Username).Msg("Stored rating")
+		}
+	}
+	if !p.WritesToStash() {
+		return
+	}
+
 	_, err := gql.SceneUpdateRating100(ctx, client, sceneId, newRating)
 	if err != nil {
 		log.Ctx(ctx).Warn().Err(err).Int("rating", newRating).Msg("Failed to update rating")
@@ -133,7 +173,7 @@ func updateRating(ctx context.Context, client graphql.Client, sceneId string, ra
 }
 
 func updateFavorite(ctx context.Context, client graphql.Client, sceneId string, isFavoriteRequested bool) {
-	favoriteTagName := config.Get().FavoriteTag
+	favoriteTagName := profile.FromContext(ctx).FavoriteTag
 
 	if favoriteTagName == "" {
 		log.Ctx(ctx).Info().Msg("Sync favorite requested but FAVORITE_TAG is empty, ignoring request")
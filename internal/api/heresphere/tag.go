@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"stash-vr/internal/api/internal"
-	"stash-vr/internal/config"
+	"stash-vr/internal/profile"
 	"stash-vr/internal/stash/gql"
 	"stash-vr/internal/util"
 )
@@ -94,7 +94,7 @@ func getFields(s gql.SceneScanParts) []tag {
 func getStashTags(s gql.SceneScanParts) []tag {
 	tags := make([]tag, 0, len(s.Tags))
 	for _, t := range s.Tags {
-		if t.Name == config.Get().FavoriteTag {
+		if profile.IsFavoriteTag(t.Name) {
 			continue
 		}
 		t := tag{
@@ -6,9 +6,13 @@ import (
 	"path/filepath"
 	"regexp"
 	"stash-vr/internal/api/heatmap"
+	"stash-vr/internal/api/internal"
 	"stash-vr/internal/config"
+	"stash-vr/internal/profile"
+	"stash-vr/internal/sections/section"
 	"stash-vr/internal/stash"
 	"stash-vr/internal/stash/gql"
+	"strconv"
 	"strings"
 
 	"github.com/Khan/genqlient/graphql"
@@ -60,8 +64,9 @@ type script struct {
 	Url  string `json:"url"`
 }
 
-func buildVideoData(ctx context.Context, client graphql.Client, baseUrl string, sceneId string, includeMediaSource bool) (videoData, error) {
-	findSceneResponse, err := gql.FindSceneFull(ctx, client, sceneId)
+// buildVideoData builds the video data of a scene, or of a marker entry if markerId is set.
+func buildVideoData(ctx context.Context, client graphql.Client, baseUrl string, sceneId string, markerId string, includeMediaSource bool) (videoData, error) {
+	findSceneResponse, err := stash.FindSceneFull(ctx, client, sceneId)
 	if err != nil {
 		return videoData{}, fmt.Errorf("FindSceneFull: %w", err)
 	}
@@ -84,8 +89,10 @@ func buildVideoData(ctx context.Context, client graphql.Client, baseUrl string,
 		title = s.SceneScanParts.Files[0].Basename
 	}
 
+	p := profile.FromContext(ctx)
+
 	vd := videoData{
-		Access:         1,
+		Access:         accessMember,
 		Title:          title,
 		Description:    s.Details,
 		ThumbnailImage: thumbnailUrl,
@@ -93,14 +100,14 @@ func buildVideoData(ctx context.Context, client graphql.Client, baseUrl string,
 		DateReleased:   s.Date,
 		DateAdded:      s.Created_at.Format("2006-01-02"),
 		Duration:       s.SceneScanParts.Files[0].Duration * 1000,
-		Rating:         float32(s.Rating100) / 20.0,
+		Rating:         float32(getRating100(p, s.SceneScanParts)) / 20.0,
 		Favorites:      s.O_counter,
 		WriteFavorite:  true,
 		WriteRating:    true,
 		WriteTags:      true,
 	}
 
-	setIsFavorite(s, &vd)
+	setIsFavorite(p, s, &vd)
 
 	if includeMediaSource {
 		setMediaSources(ctx, s, &vd)
@@ -110,9 +117,33 @@ func buildVideoData(ctx context.Context, client graphql.Client, baseUrl string,
 	setTags(s, &vd)
 
 	setScripts(s, &vd)
+
+	if markerId != "" {
+		if err := setMarker(s, markerId, &vd); err != nil {
+			return videoData{}, err
+		}
+	}
 	return vd, nil
 }
 
+// setMarker titles and thumbnails videoData as the marker and starts its media at the marker,
+// using a media fragment since video data has no start time.
+func setMarker(s gql.SceneFullParts, markerId string, videoData *videoData) error {
+	sm, err := internal.FindMarker(s.SceneScanParts, markerId)
+	if err != nil {
+		return err
+	}
+	videoData.Title = section.MarkerTitle(sm.Primary_tag.Name, sm.Title)
+	videoData.ThumbnailImage = stash.ApiKeyed(sm.Screenshot)
+	fragment := "#t=" + strconv.FormatFloat(sm.Seconds, 'f', -1, 64)
+	for i := range videoData.Media {
+		for j := range videoData.Media[i].Sources {
+			videoData.Media[i].Sources[j].Url += fragment
+		}
+	}
+	return nil
+}
+
 func setTags(s gql.SceneFullParts, videoData *videoData) {
 	tags := getTags(s.SceneScanParts)
 	videoData.Tags = tags
@@ -244,15 +275,22 @@ func setMediaSources(ctx context.Context, s gql.SceneFullParts, videoData *video
 	}
 }
 
-func setIsFavorite(s gql.SceneFullParts, videoData *videoData) {
-	videoData.IsFavorite = ContainsFavoriteTag(s.TagPartsArray)
+func setIsFavorite(p profile.Profile, s gql.SceneFullParts, videoData *videoData) {
+	videoData.IsFavorite = ContainsFavoriteTag(s.TagPartsArray, p.FavoriteTag)
 }
 
-func ContainsFavoriteTag(ts gql.TagPartsArray) bool {
+func ContainsFavoriteTag(ts gql.TagPartsArray, favoriteTag string) bool {
 	for _, t := range ts.Tags {
-		if t.Name == config.Get().FavoriteTag {
+		if t.Name == favoriteTag {
 			return true
 		}
 	}
 	return false
 }
+
+func getRating100(p profile.Profile, s gql.SceneScanParts) int {
+	if p.IsLocal {
+		return p.Scene(s.Id).Rating100
+	}
+	return s.Rating100
+}
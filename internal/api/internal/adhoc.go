@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"stash-vr/internal/sections"
+	"stash-vr/internal/stash/filter/query"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+)
+
+var errEmptyAdHoc = errors.New("no query or filter given, use /q/<query or filter name>, ?q=<query or filter name> or ?filter=<filter name>")
+
+// AdHocRequest reads the one-off library of a /q request from the path after /q/, or from the parameters q or filter.
+// The /scan suffix of a scan data request is not part of the library.
+func AdHocRequest(req *http.Request) (sections.AdHocRequest, error) {
+	expr := chi.URLParam(req, "*")
+	if IsAdHocScan(req) {
+		expr = strings.TrimSuffix(expr, "/scan")
+	}
+	if req.URL.RawPath != "" {
+		// chi routes by the escaped path when it differs from the default escaping, e.g. for an escaped slash
+		unescaped, err := url.PathUnescape(expr)
+		if err != nil {
+			return sections.AdHocRequest{}, fmt.Errorf("path: %w", err)
+		}
+		expr = unescaped
+	}
+	if expr == "" {
+		expr = req.URL.Query().Get("q")
+	}
+	r := sections.AdHocRequest{Expr: expr, FilterName: req.URL.Query().Get("filter")}
+	if r.Expr == "" && r.FilterName == "" {
+		return r, errEmptyAdHoc
+	}
+	return r, nil
+}
+
+// IsAdHocScan reports whether a /q request asks for the scan data of its library, HereSphere requests the library url
+// followed by /scan. A query ending in /scan escapes its slash as %2F, chi matches the escaped path then.
+func IsAdHocScan(req *http.Request) bool {
+	return strings.HasSuffix(chi.URLParam(req, "*"), "/scan")
+}
+
+// AdHocLogContext adds the ad-hoc request to the logger of ctx.
+func AdHocLogContext(ctx context.Context, r sections.AdHocRequest) context.Context {
+	return log.Ctx(ctx).With().Str("expr", r.Expr).Str("filterName", r.FilterName).Logger().WithContext(ctx)
+}
+
+// WriteAdHocError responds with the problem of an ad-hoc request as text, e.g. the column of a query error.
+func WriteAdHocError(ctx context.Context, w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var queryError *query.Error
+	switch {
+	case errors.As(err, &queryError), errors.Is(err, errEmptyAdHoc):
+		status = http.StatusBadRequest
+	case errors.Is(err, sections.ErrFilterNotFound):
+		status = http.StatusNotFound
+	}
+	log.Ctx(ctx).Warn().Err(err).Int("status", status).Msg("Ad-hoc library failed")
+	http.Error(w, err.Error(), status)
+}
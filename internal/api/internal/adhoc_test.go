@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestIsAdHocScan(t *testing.T) {
+	tests := []struct {
+		target string
+		want   bool
+	}{
+		{"/q/tags:POV/scan", true},
+		{"/q/Top%20rated/scan", true},
+		{"/q/tags:POV", false},
+		{"/q/title:%22a%2Fscan%22", false},
+		{"/q/title:a%2Fscan", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			var got bool
+			r := chi.NewRouter()
+			r.Post("/q/*", func(w http.ResponseWriter, req *http.Request) {
+				got = IsAdHocScan(req)
+			})
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, tt.target, nil))
+			if got != tt.want {
+				t.Errorf("IsAdHocScan() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
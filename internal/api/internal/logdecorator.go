@@ -16,7 +16,11 @@ func LogRoute(route string, next http.HandlerFunc) http.HandlerFunc {
 func LogVideoId(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		videoId := chi.URLParam(r, "videoId")
-		ctx := log.Ctx(r.Context()).With().Str("videoId", videoId).Logger().WithContext(r.Context())
+		logCtx := log.Ctx(r.Context()).With().Str("videoId", videoId)
+		if markerId := chi.URLParam(r, "markerId"); markerId != "" {
+			logCtx = logCtx.Str("markerId", markerId)
+		}
+		ctx := logCtx.Logger().WithContext(r.Context())
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
@@ -0,0 +1,16 @@
+package internal
+
+import (
+	"fmt"
+	"stash-vr/internal/stash/gql"
+)
+
+// FindMarker returns the marker of s with id markerId, for the video data of a marker entry.
+func FindMarker(s gql.SceneScanParts, markerId string) (*gql.SceneScanPartsScene_markersSceneMarker, error) {
+	for _, sm := range s.Scene_markers {
+		if sm.Id == markerId {
+			return sm, nil
+		}
+	}
+	return nil, fmt.Errorf("marker %s not found in scene %s", markerId, s.Id)
+}
@@ -6,10 +6,15 @@ import (
 	"html/template"
 	"net/http"
 	"stash-vr/internal/application"
+	"stash-vr/internal/auth"
+	"stash-vr/internal/cache"
 	"stash-vr/internal/config"
 	"stash-vr/internal/sections"
+	"stash-vr/internal/sections/diagnostic"
 	"stash-vr/internal/stash/gql"
+	"stash-vr/internal/util"
 	"strings"
+	"time"
 )
 
 var tmpl = template.Must(template.ParseFiles("web/template/index.html"))
@@ -23,9 +28,12 @@ const (
 type indexData struct {
 	Redact                  func(string) string
 	Version                 string
+	BaseUrl                 string
 	LogLevel                string
 	ForceHTTPS              bool
 	IsSyncMarkersAllowed    bool
+	IsAuthEnabled           bool
+	Username                string
 	StashGraphQLUrl         string
 	IsApiKeyProvided        bool
 	StashConnectionResponse string
@@ -33,6 +41,21 @@ type indexData struct {
 	SectionCount            int
 	LinkCount               int
 	SceneCount              int
+	SectionsAge             string
+	Sections                []sectionRow
+	SectionsFileProblems    []string
+	IsStrictFilters         bool
+	FilterDiagnostics       []diagnostic.Diagnostic
+	FilterProblemCount      int
+	Caches                  []cache.Stats
+	Discover                sections.DiscoverSeed
+}
+
+type sectionRow struct {
+	Name           string
+	Age            string
+	FilterDuration string
+	Scenes         int
 }
 
 func IndexHandler(client graphql.Client) http.HandlerFunc {
@@ -40,9 +63,12 @@ func IndexHandler(client graphql.Client) http.HandlerFunc {
 		data := indexData{
 			Redact:                  config.Redacted,
 			Version:                 application.BuildVersion,
+			BaseUrl:                 util.GetBaseUrl(r),
 			LogLevel:                config.Get().LogLevel,
 			ForceHTTPS:              config.Get().ForceHTTPS,
 			IsSyncMarkersAllowed:    config.Get().IsSyncMarkersAllowed,
+			IsAuthEnabled:           auth.IsEnabled(),
+			Username:                auth.Username(r.Context()),
 			StashGraphQLUrl:         config.Get().StashGraphQLUrl,
 			IsApiKeyProvided:        config.Get().StashApiKey != "",
 			StashConnectionResponse: fail,
@@ -56,6 +82,25 @@ func IndexHandler(client graphql.Client) http.HandlerFunc {
 			count := sections.Count(ss)
 			data.LinkCount = count.Links
 			data.SceneCount = count.Scenes
+			data.SectionsAge = time.Since(sections.UpdatedAt(r.Context())).Round(time.Second).String()
+			for _, s := range ss {
+				data.Sections = append(data.Sections, sectionRow{
+					Name:           s.Name,
+					Age:            time.Since(s.BuiltAt).Round(time.Second).String(),
+					FilterDuration: s.FilterDuration.Round(time.Millisecond).String(),
+					Scenes:         s.Len(),
+				})
+			}
+			data.Caches = cache.All()
+			data.Discover = sections.CurrentDiscoverSeed()
+			data.SectionsFileProblems = sections.FileProblems()
+			data.IsStrictFilters = config.Get().IsStrictFilters
+			data.FilterDiagnostics = diagnostic.All()
+			for _, d := range data.FilterDiagnostics {
+				if d.Status() != diagnostic.StatusOk {
+					data.FilterProblemCount++
+				}
+			}
 		} else {
 			if strings.HasSuffix(err.Error(), "unauthorized") {
 				data.StashConnectionResponse = unauthorized
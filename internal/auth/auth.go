@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"stash-vr/internal/config"
+)
+
+type ctxKey struct{}
+
+// IsEnabled reports whether clients need to log in to access Stash-VR.
+func IsEnabled() bool {
+	return config.Get().AuthUsername != "" || len(config.Get().Users) > 0
+}
+
+// Authenticate reports whether username and password match a configured login.
+func Authenticate(username string, password string) bool {
+	expected, ok := lookupPassword(username)
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(password), []byte(expected)) == 1
+}
+
+func lookupPassword(username string) (string, bool) {
+	if username == "" {
+		return "", false
+	}
+	if username == config.Get().AuthUsername {
+		return config.Get().AuthPassword, true
+	}
+	for _, u := range config.Get().Users {
+		if u.Username == username {
+			return u.Password, true
+		}
+	}
+	return "", false
+}
+
+func WithUsername(ctx context.Context, username string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, username)
+}
+
+// Username returns the authenticated user of the request context, empty if auth is disabled.
+func Username(ctx context.Context) string {
+	username, _ := ctx.Value(ctxKey{}).(string)
+	return username
+}
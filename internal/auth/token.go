@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"stash-vr/internal/config"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+const secretFileName = "auth.key"
+
+var (
+	secretOnce sync.Once
+	secretKey  []byte
+)
+
+// secret returns the key used to sign tokens. It's persisted in DATA_DIR so issued tokens survive restarts.
+func secret() []byte {
+	secretOnce.Do(func() {
+		path := filepath.Join(config.Get().DataDir, secretFileName)
+		if b, err := os.ReadFile(path); err == nil && len(b) >= 32 {
+			secretKey = b
+			return
+		}
+		secretKey = make([]byte, 32)
+		if _, err := rand.Read(secretKey); err != nil {
+			log.Fatal().Err(err).Msg("Failed to generate auth secret")
+		}
+		if err := os.MkdirAll(config.Get().DataDir, 0700); err != nil {
+			log.Warn().Err(err).Msg("Failed to persist auth secret, tokens will be invalidated on restart")
+			return
+		}
+		if err := os.WriteFile(path, secretKey, 0600); err != nil {
+			log.Warn().Err(err).Msg("Failed to persist auth secret, tokens will be invalidated on restart")
+		}
+	})
+	return secretKey
+}
+
+func mac(parts ...string) []byte {
+	h := hmac.New(sha256.New, secret())
+	h.Write([]byte(strings.Join(parts, "\x00")))
+	return h.Sum(nil)
+}
+
+// NewToken issues a token for a logged-in user. Changing the user's password invalidates it.
+func NewToken(username string) string {
+	password, _ := lookupPassword(username)
+	return base64.RawURLEncoding.EncodeToString([]byte(username)) + "." +
+		base64.RawURLEncoding.EncodeToString(mac("token", username, password))
+}
+
+// VerifyToken returns the user a token was issued to.
+// The static AUTH_TOKEN is accepted as well and maps to the configured AUTH_USERNAME, the default profile if only users
+// are configured.
+func VerifyToken(token string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+	if static := config.Get().AuthToken; static != "" && subtle.ConstantTimeCompare([]byte(token), []byte(static)) == 1 {
+		return config.Get().AuthUsername, true
+	}
+	encodedUsername, encodedMac, found := strings.Cut(token, ".")
+	if !found {
+		return "", false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(encodedUsername)
+	if err != nil {
+		return "", false
+	}
+	username := string(b)
+	password, ok := lookupPassword(username)
+	if !ok {
+		return "", false
+	}
+	got, err := base64.RawURLEncoding.DecodeString(encodedMac)
+	if err != nil || !hmac.Equal(got, mac("token", username, password)) {
+		return "", false
+	}
+	return username, true
+}
+
+// Sign returns a signature granting access to a single resource, for links fetched by players without credentials.
+func Sign(resource string) string {
+	return base64.RawURLEncoding.EncodeToString(mac("resource", resource))
+}
+
+func VerifySignature(resource string, signature string) bool {
+	got, err := base64.RawURLEncoding.DecodeString(signature)
+	return err == nil && hmac.Equal(got, mac("resource", resource))
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RequireWeb protects browser and api endpoints. Accepted are basic auth, a bearer token or a `token` query parameter.
+func RequireWeb(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsEnabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		username, ok := fromRequest(r)
+		if !ok {
+			log.Ctx(r.Context()).Debug().Msg("Unauthorized request")
+			w.Header().Set("WWW-Authenticate", `Basic realm="Stash-VR", charset="UTF-8"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithUsername(r.Context(), username)))
+	})
+}
+
+func fromRequest(r *http.Request) (string, bool) {
+	if username, password, ok := r.BasicAuth(); ok {
+		return username, Authenticate(username, password)
+	}
+	if authorization := r.Header.Get("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+		return VerifyToken(strings.TrimPrefix(authorization, "Bearer "))
+	}
+	return VerifyToken(r.URL.Query().Get("token"))
+}
@@ -2,39 +2,237 @@ package cache
 
 import (
 	"context"
-	"github.com/rs/zerolog/log"
+	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
 )
 
-type Cache[T any] struct {
-	dataLock   sync.RWMutex
-	fetchMutex sync.Mutex
-	data       *T
-}
-
-func (c *Cache[T]) Get(ctx context.Context, fetch func(ctx context.Context) T) T {
-	c.dataLock.Lock()
-	defer c.dataLock.Unlock()
-
-	if c.data == nil {
-		d := fetch(ctx)
-		c.data = &d
-		return *c.data
-	}
-
-	go func(ctx context.Context) {
-		ctx = log.Ctx(ctx).With().Str("op", "bg").Logger().WithContext(context.Background())
-		if c.fetchMutex.TryLock() {
-			defer c.fetchMutex.Unlock()
-			log.Ctx(ctx).Trace().Msg("Prefetching...")
-			d := fetch(ctx)
-			c.dataLock.Lock()
-			defer c.dataLock.Unlock()
-			c.data = &d
-		} else {
-			log.Ctx(ctx).Trace().Msg("Already fetching...")
+// fetchTimeout bounds a fetch, which isn't cancelled with the request that started it.
+const fetchTimeout = 5 * time.Minute
+
+type Options struct {
+	// TTL is how long a value is fresh. Stale values are still served while refreshed in the background.
+	// Zero means values never go stale.
+	TTL time.Duration
+	// MaxEntries evicts the least recently fetched entries when exceeded. Zero means unbounded.
+	MaxEntries int
+}
+
+// Cache is a keyed cache with stale-while-revalidate semantics.
+// Concurrent fetches of the same key are coalesced into one.
+type Cache[K comparable, V any] struct {
+	name    string
+	options Options
+
+	mu      sync.RWMutex
+	entries map[K]*entry[V]
+	// generation counts invalidations, a fetch started before one is outdated.
+	generation uint64
+	group      singleflight.Group
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+type entry[V any] struct {
+	value     V
+	fetchedAt time.Time
+}
+
+type Stats struct {
+	Name    string `json:"name"`
+	Entries int    `json:"entries"`
+	Hits    int64  `json:"hits"`
+	Misses  int64  `json:"misses"`
+}
+
+type statser interface {
+	Stats() Stats
+}
+
+var registry struct {
+	mu     sync.Mutex
+	caches []statser
+}
+
+// New creates a cache and registers it for All.
+func New[K comparable, V any](name string, options Options) *Cache[K, V] {
+	c := &Cache[K, V]{
+		name:    name,
+		options: options,
+		entries: make(map[K]*entry[V]),
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.caches = append(registry.caches, c)
+	return c
+}
+
+// All returns the stats of all caches created with New.
+func All() []Stats {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	stats := make([]Stats, len(registry.caches))
+	for i, c := range registry.caches {
+		stats[i] = c.Stats()
+	}
+	return stats
+}
+
+// Get returns the cached value of key, fetching it on a miss.
+// A stale value is returned as is and refreshed in the background.
+func (c *Cache[K, V]) Get(ctx context.Context, key K, fetch func(ctx context.Context) (V, error)) (V, error) {
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if ok {
+		c.hits.Add(1)
+		if c.options.TTL > 0 && time.Since(e.fetchedAt) > c.options.TTL {
+			go func() {
+				ctx := log.Ctx(ctx).With().Str("op", "bg").Str("cache", c.name).Logger().WithContext(context.Background())
+				if _, err := c.Refresh(ctx, key, fetch); err != nil {
+					log.Ctx(ctx).Warn().Err(err).Msg("Failed to refresh stale value, keeping it")
+				}
+			}()
+		}
+		return e.value, nil
+	}
+
+	c.misses.Add(1)
+	return c.Refresh(ctx, key, fetch)
+}
+
+// Refresh fetches and stores a new value of key, readers are served the previous value meanwhile.
+// On error the previous value is kept. The fetch is shared by concurrent callers, it isn't cancelled with ctx.
+// A value fetched while the cache is invalidated isn't stored, and callers after the invalidation fetch anew.
+func (c *Cache[K, V]) Refresh(ctx context.Context, key K, fetch func(ctx context.Context) (V, error)) (V, error) {
+	c.mu.RLock()
+	generation := c.generation
+	c.mu.RUnlock()
+
+	v, err, _ := c.group.Do(fmt.Sprintf("%d/%v", generation, key), func() (any, error) {
+		ctx, cancel := context.WithTimeout(detached{ctx}, fetchTimeout)
+		defer cancel()
+		v, err := fetch(ctx)
+		if err != nil {
+			return v, err
+		}
+		if !c.set(key, v, time.Now(), generation) {
+			log.Ctx(ctx).Debug().Str("cache", c.name).Msg("Discarded value fetched before invalidation")
 		}
-	}(ctx)
-	return *c.data
+		return v, nil
+	})
+	return v.(V), err
+}
+
+// Set stores a value fetched at the given time.
+func (c *Cache[K, V]) Set(key K, value V, fetchedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = &entry[V]{value: value, fetchedAt: fetchedAt}
+	c.evict()
+}
+
+// set stores a value fetched at the given time unless the cache was invalidated since generation.
+func (c *Cache[K, V]) set(key K, value V, fetchedAt time.Time, generation uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return false
+	}
+	c.entries[key] = &entry[V]{value: value, fetchedAt: fetchedAt}
+	c.evict()
+	return true
+}
+
+// evict must be called with mu held.
+func (c *Cache[K, V]) evict() {
+	for c.options.MaxEntries > 0 && len(c.entries) > c.options.MaxEntries {
+		var oldestKey K
+		var oldest *entry[V]
+		for k, e := range c.entries {
+			if oldest == nil || e.fetchedAt.Before(oldest.fetchedAt) {
+				oldestKey, oldest = k, e
+			}
+		}
+		delete(c.entries, oldestKey)
+	}
+}
+
+// Contains reports whether a value, fresh or stale, is cached for key.
+func (c *Cache[K, V]) Contains(key K) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.entries[key]
+	return ok
+}
+
+// FetchedAt returns when the value of key was fetched, zero if not cached.
+func (c *Cache[K, V]) FetchedAt(key K) time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if e, ok := c.entries[key]; ok {
+		return e.fetchedAt
+	}
+	return time.Time{}
+}
+
+// Keys returns the keys of all cached values.
+func (c *Cache[K, V]) Keys() []K {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	keys := make([]K, 0, len(c.entries))
+	for k := range c.entries {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Invalidate removes the value of key, the next Get fetches it.
+func (c *Cache[K, V]) Invalidate(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	c.generation++
+}
+
+// InvalidateAll removes all values.
+func (c *Cache[K, V]) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[K]*entry[V])
+	c.generation++
+}
+
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return Stats{
+		Name:    c.name,
+		Entries: len(c.entries),
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+	}
+}
+
+// detached carries the values of a context, e.g. its logger, but not its cancellation.
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detached) Done() <-chan struct{} {
+	return nil
+}
 
+func (detached) Err() error {
+	return nil
 }
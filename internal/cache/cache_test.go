@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache_Get(t *testing.T) {
+	c := New[string, int]("test", Options{})
+	var fetches atomic.Int64
+	fetch := func(ctx context.Context) (int, error) {
+		fetches.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return 1, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := c.Get(context.Background(), "a", fetch); err != nil || v != 1 {
+				t.Errorf("Get() = %v, %v, want 1, nil", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("fetches = %d, want 1", got)
+	}
+	if _, err := c.Get(context.Background(), "a", fetch); err != nil {
+		t.Fatal(err)
+	}
+	if s := c.Stats(); s.Hits+s.Misses != 11 || s.Entries != 1 {
+		t.Errorf("Stats() = %+v", s)
+	}
+}
+
+func TestCache_Refresh(t *testing.T) {
+	c := New[string, int]("test", Options{})
+	c.Set("a", 1, time.Now())
+
+	if _, err := c.Refresh(context.Background(), "a", func(ctx context.Context) (int, error) {
+		return 0, errors.New("failed")
+	}); err == nil {
+		t.Error("Refresh() error = nil, want error")
+	}
+	v, _ := c.Get(context.Background(), "a", nil)
+	if v != 1 {
+		t.Errorf("Get() after failed refresh = %d, want 1", v)
+	}
+
+	c.Invalidate("a")
+	if c.Contains("a") {
+		t.Error("Contains() after Invalidate = true")
+	}
+}
+
+func TestCache_evict(t *testing.T) {
+	c := New[int, int]("test", Options{MaxEntries: 2})
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		c.Set(i, i, now.Add(time.Duration(i)*time.Second))
+	}
+	if c.Contains(0) || !c.Contains(1) || !c.Contains(2) {
+		t.Errorf("Keys() = %v, want [1 2]", c.Keys())
+	}
+}
+
+func TestCache_Refresh_cancelledCaller(t *testing.T) {
+	c := New[string, int]("test", Options{})
+	started := make(chan struct{})
+	fetch := func(ctx context.Context) (int, error) {
+		close(started)
+		time.Sleep(20 * time.Millisecond)
+		return 1, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go c.Get(ctx, "a", fetch)
+	<-started
+	cancel()
+	if v, err := c.Get(context.Background(), "a", fetch); err != nil || v != 1 {
+		t.Errorf("Get() = %v, %v, want 1, nil", v, err)
+	}
+}
+
+func TestCache_Refresh_invalidated(t *testing.T) {
+	c := New[string, int]("test", Options{})
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Get(context.Background(), "a", func(ctx context.Context) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+	}()
+	<-started
+	c.Invalidate("a")
+
+	// not joined with the fetch started before the invalidation
+	if v, err := c.Get(context.Background(), "a", func(ctx context.Context) (int, error) { return 2, nil }); err != nil || v != 2 {
+		t.Errorf("Get() after Invalidate = %v, %v, want 2, nil", v, err)
+	}
+	close(release)
+	<-done
+	if v, _ := c.Get(context.Background(), "a", nil); v != 2 {
+		t.Errorf("Get() = %d, want 2, the value fetched before the invalidation is discarded", v)
+	}
+}
@@ -2,45 +2,80 @@ package config
 
 import (
 	"os"
-	"strconv"
-	"strings"
 	"sync"
 
 	"github.com/rs/zerolog/log"
 )
 
-const (
-	envKeyStashGraphQLUrl  = "STASH_GRAPHQL_URL"
-	envKeyStashApiKey      = "STASH_API_KEY"
-	envKeyFavoriteTag      = "FAVORITE_TAG"
-	envPassthroughTag      = "PASSTHROUGH_TAG"
-	envKeyFilters          = "FILTERS"
-	envKeyLogLevel         = "LOG_LEVEL"
-	envKeyDisableRedact    = "DISABLE_REDACT"
-	envKeyForceHTTPS       = "FORCE_HTTPS"
-	envKeyDisableHeatmap   = "DISABLE_HEATMAP"
-	envKeyHeatmapHeightPx  = "HEATMAP_HEIGHT_PX"
-	envKeyAllowSyncMarkers = "ALLOW_SYNC_MARKERS"
-	envKeyDisablePlayCount = "DISABLE_PLAY_COUNT"
-	envVrDetection         = "VR_DETECTION"
-)
-
 var deprecatedEnvKeys = []string{"ENABLE_GLANCE_MARKERS", "HERESPHERE_QUICK_MARKERS", "HERESPHERE_SYNC_MARKERS", "ENABLE_HEATMAP_DISPLAY"}
 
+// Application is the typed schema shared by the config file and the environment.
+// Fields are read from the config file by their `yaml` key and then overridden by the environment variable named in `env`.
 type Application struct {
-	StashGraphQLUrl      string
-	StashApiKey          string
-	FavoriteTag          string
-	PassThroughTag       string
-	Filters              string
-	IsSyncMarkersAllowed bool
-	LogLevel             string
-	IsRedactDisabled     bool
-	ForceHTTPS           bool
-	IsHeatmapDisabled    bool
-	HeatmapHeightPx      int
-	IsPlayCountDisabled  bool
-	UseVrDetection       bool
+	ListenAddress         string `yaml:"listen_address" env:"LISTEN_ADDRESS"`
+	ListenPort            int    `yaml:"listen_port" env:"LISTEN_PORT"`
+	PublicUrl             string `yaml:"public_url" env:"PUBLIC_URL"`
+	IsHTTPSEnabled        bool   `yaml:"enable_https" env:"ENABLE_HTTPS"`
+	TLSCertFile           string `yaml:"tls_cert_file" env:"TLS_CERT_FILE"`
+	TLSKeyFile            string `yaml:"tls_key_file" env:"TLS_KEY_FILE"`
+	HTTPPort              int    `yaml:"http_port" env:"HTTP_PORT"`
+	IsHTTPRedirected      bool   `yaml:"redirect_http" env:"REDIRECT_HTTP"`
+	DataDir               string `yaml:"data_dir" env:"DATA_DIR"`
+	AuthUsername          string `yaml:"auth_username" env:"AUTH_USERNAME"`
+	AuthPassword          string `yaml:"auth_password" env:"AUTH_PASSWORD"`
+	AuthToken             string `yaml:"auth_token" env:"AUTH_TOKEN"`
+	Users                 []User `yaml:"users"`
+	StashGraphQLUrl       string `yaml:"stash_graphql_url" env:"STASH_GRAPHQL_URL"`
+	StashApiKey           string `yaml:"stash_api_key" env:"STASH_API_KEY"`
+	FavoriteTag           string `yaml:"favorite_tag" env:"FAVORITE_TAG"`
+	PassThroughTag        string `yaml:"passthrough_tag" env:"PASSTHROUGH_TAG"`
+	Filters               string `yaml:"filters" env:"FILTERS"`
+	SectionsFile          string `yaml:"sections_file" env:"SECTIONS_FILE"`
+	MaxLinks              int    `yaml:"max_links" env:"MAX_LINKS"`
+	SectionMaxScenes      int    `yaml:"section_max_scenes" env:"SECTION_MAX_SCENES"`
+	IsSplitSections       bool   `yaml:"split_sections" env:"SPLIT_SECTIONS"`
+	IsDedupeScenes        bool   `yaml:"dedupe_scenes" env:"DEDUPE_SCENES"`
+	IsStrictFilters       bool   `yaml:"strict_filters" env:"STRICT_FILTERS"`
+	FolderRoots           string `yaml:"folder_roots" env:"FOLDER_ROOTS"`
+	FolderDepth           int    `yaml:"folder_depth" env:"FOLDER_DEPTH"`
+	IsSyncMarkersAllowed  bool   `yaml:"allow_sync_markers" env:"ALLOW_SYNC_MARKERS"`
+	LogLevel              string `yaml:"log_level" env:"LOG_LEVEL"`
+	IsRedactDisabled      bool   `yaml:"disable_redact" env:"DISABLE_REDACT"`
+	ForceHTTPS            bool   `yaml:"force_https" env:"FORCE_HTTPS"`
+	IsHeatmapDisabled     bool   `yaml:"disable_heatmap" env:"DISABLE_HEATMAP"`
+	HeatmapHeightPx       int    `yaml:"heatmap_height_px" env:"HEATMAP_HEIGHT_PX"`
+	IsPlayCountDisabled   bool   `yaml:"disable_play_count" env:"DISABLE_PLAY_COUNT"`
+	UseVrDetection        bool   `yaml:"vr_detection" env:"VR_DETECTION"`
+	IsStashEventsDisabled bool   `yaml:"disable_stash_events" env:"DISABLE_STASH_EVENTS"`
+	StashPollIntervalSec  int    `yaml:"stash_poll_interval_sec" env:"STASH_POLL_INTERVAL_SEC"`
+}
+
+// User is a login with its own profile. Users can only be configured in the config file.
+type User struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// FavoriteTag is the Stash tag holding this user's favorites. Defaults to "<favorite_tag> (<username>)".
+	FavoriteTag string `yaml:"favorite_tag"`
+	// Filters overrides the global filters for this user's sections.
+	Filters string `yaml:"filters"`
+	// IsStashSyncEnabled writes ratings and play counts to Stash in addition to the local store.
+	IsStashSyncEnabled bool `yaml:"sync_to_stash"`
+}
+
+// Default returns the configuration used when neither config file nor environment sets a value.
+func Default() Application {
+	return Application{
+		ListenAddress:        "0.0.0.0",
+		ListenPort:           9666,
+		DataDir:              "data",
+		StashGraphQLUrl:      "http://localhost:9999/graphql",
+		FavoriteTag:          "Favourite",
+		PassThroughTag:       "Passthrough",
+		LogLevel:             "info",
+		StashPollIntervalSec: 60,
+		SectionsFile:         "sections.yml",
+		FolderDepth:          1,
+	}
 }
 
 var cfg Application
@@ -50,20 +85,15 @@ var once sync.Once
 func Get() Application {
 	once.Do(func() {
 		logDeprecatedKeysInUse()
-		cfg = Application{
-			StashGraphQLUrl:      getEnvOrDefaultStr(envKeyStashGraphQLUrl, "http://192.168.100.28:9997/graphql"),
-			StashApiKey:          getEnvOrDefaultStr(envKeyStashApiKey, ""),
-			FavoriteTag:          getEnvOrDefaultStr(envKeyFavoriteTag, "Favourite"),
-			PassThroughTag:       getEnvOrDefaultStr(envPassthroughTag, "Passthrough"),
-			Filters:              getEnvOrDefaultStr(envKeyFilters, "27,33,40,19,42,31,47,44,48,32,21,22,23"),
-			IsSyncMarkersAllowed: getEnvOrDefaultBool(envKeyAllowSyncMarkers, false),
-			LogLevel:             strings.ToLower(getEnvOrDefaultStr(envKeyLogLevel, "info")),
-			IsRedactDisabled:     getEnvOrDefaultBool(envKeyDisableRedact, false),
-			ForceHTTPS:           getEnvOrDefaultBool(envKeyForceHTTPS, false),
-			IsHeatmapDisabled:    getEnvOrDefaultBool(envKeyDisableHeatmap, false),
-			HeatmapHeightPx:      getEnvOrDefaultInt(envKeyHeatmapHeightPx, 0),
-			IsPlayCountDisabled:  getEnvOrDefaultBool(envKeyDisablePlayCount, false),
-			UseVrDetection:       getEnvOrDefaultBool(envVrDetection, false),
+		var err error
+		cfg, err = Load(filePath())
+		if err != nil {
+			if v, ok := err.(*ValidationError); ok {
+				for _, p := range v.Problems {
+					log.Error().Str("problem", p).Msg("Invalid configuration")
+				}
+			}
+			log.Fatal().Err(err).Msg("Failed to load configuration")
 		}
 	})
 	return cfg
@@ -78,55 +108,17 @@ func logDeprecatedKeysInUse() {
 	}
 }
 
-func getEnvOrDefaultInt(key string, defaultValue int) int {
-	s, ok := os.LookupEnv(key)
-	if !ok {
-		return defaultValue
-	}
-	val, err := strconv.Atoi(s)
-	if err != nil {
-		log.Fatal().Err(err).Str("key", key).Str("value", s).Msg("Invalid value in environment arguments. Must be an integer.")
-		return 0
-	}
-	return val
-}
-
-func getEnvOrDefaultStr(key string, defaultValue string) string {
-	val, ok := os.LookupEnv(key)
-	if !ok {
-		return defaultValue
-	}
-	return val
-}
-
-func getEnvOrDefaultBool(key string, defaultValue bool) bool {
-	s, ok := os.LookupEnv(key)
-	if !ok {
-		return defaultValue
-	}
-	val, err := strconv.ParseBool(s)
-	if err != nil {
-		log.Fatal().Err(err).Str("key", key).Str("value", s).Msg("Invalid value in environment arguments. Must be a valid boolean (1, t, T, TRUE, true, True, 0, f, F, FALSE, false, False)")
-		return false
-	}
-	return val
-}
-
-func findEnvOrDefault(keys []string, defaultValue string) string {
-	for i, key := range keys {
-		v, ok := os.LookupEnv(key)
-		if ok {
-			if i > 0 {
-				log.Warn().Str("deprecated", key).Str("replace with", keys[0]).Msg("Deprecated env. var. found")
-			}
-			return v
-		}
-	}
-	return defaultValue
-}
-
 func (a Application) Redacted() Application {
 	a.StashGraphQLUrl = Redacted(a.StashGraphQLUrl)
 	a.StashApiKey = Redacted(a.StashApiKey)
+	a.PublicUrl = Redacted(a.PublicUrl)
+	a.AuthPassword = Redacted(a.AuthPassword)
+	a.AuthToken = Redacted(a.AuthToken)
+	users := make([]User, len(a.Users))
+	for i, u := range a.Users {
+		u.Password = Redacted(u.Password)
+		users[i] = u
+	}
+	a.Users = users
 	return a
 }
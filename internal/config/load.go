@@ -0,0 +1,216 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	envKeyConfigFile  = "CONFIG_FILE"
+	flagConfigFile    = "config"
+	defaultConfigFile = "config.yml"
+)
+
+// ValidationError holds every problem found while loading the configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d configuration problem(s): %s", len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+func (e *ValidationError) add(format string, a ...any) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, a...))
+}
+
+// filePath resolves the config file from the -config flag, then CONFIG_FILE, then config.yml if present in the working directory.
+func filePath() string {
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	path := fs.String(flagConfigFile, "", "path to config file")
+	_ = fs.Parse(os.Args[1:])
+	if *path != "" {
+		return *path
+	}
+	if path, ok := os.LookupEnv(envKeyConfigFile); ok {
+		return path
+	}
+	if _, err := os.Stat(defaultConfigFile); err == nil {
+		return defaultConfigFile
+	}
+	return ""
+}
+
+// Load builds the configuration from defaults, the config file at path (if not empty) and the environment, in that order.
+// All problems are collected and returned together as a *ValidationError.
+func Load(path string) (Application, error) {
+	a := Default()
+	problems := &ValidationError{}
+
+	if path != "" {
+		if err := readFile(path, &a); err != nil {
+			problems.add("%v", err)
+		}
+	}
+
+	applyEnv(&a, os.LookupEnv, problems)
+
+	a.LogLevel = strings.ToLower(a.LogLevel)
+	a.validate(problems)
+
+	if len(problems.Problems) > 0 {
+		return Application{}, problems
+	}
+	return a, nil
+}
+
+func readFile(path string, a *Application) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(b, a); err != nil {
+		return fmt.Errorf("config file '%s': %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overrides every field tagged with `env` that is present in the environment.
+func applyEnv(a *Application, lookup func(string) (string, bool), problems *ValidationError) {
+	v := reflect.ValueOf(a).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		s, ok := lookup(key)
+		if !ok {
+			continue
+		}
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(s)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				problems.add("%s='%s': must be a valid boolean (1, t, T, TRUE, true, True, 0, f, F, FALSE, false, False)", key, s)
+				continue
+			}
+			field.SetBool(b)
+		case reflect.Int:
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				problems.add("%s='%s': must be an integer", key, s)
+				continue
+			}
+			field.SetInt(int64(n))
+		default:
+			problems.add("%s: unsupported field type %s", key, field.Kind())
+		}
+	}
+}
+
+// filtersValidator returns the problems of a FILTERS list, whose sources are defined by the sections.
+var filtersValidator func(key string, filters string) []string
+
+// SetFiltersValidator sets the check of FILTERS lists, call it before Get loads the configuration.
+func SetFiltersValidator(validate func(key string, filters string) []string) {
+	filtersValidator = validate
+}
+
+func (a Application) validate(problems *ValidationError) {
+	if a.StashGraphQLUrl == "" {
+		problems.add("stash_graphql_url/STASH_GRAPHQL_URL: required")
+	} else if u, err := url.Parse(a.StashGraphQLUrl); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		problems.add("stash_graphql_url/STASH_GRAPHQL_URL='%s': must be an absolute http(s) url, e.g. http://localhost:9999/graphql", redact(a.StashGraphQLUrl, a.IsRedactDisabled))
+	}
+
+	if a.ListenPort < 1 || a.ListenPort > 65535 {
+		problems.add("listen_port/LISTEN_PORT=%d: must be between 1 and 65535", a.ListenPort)
+	}
+
+	if (a.TLSCertFile == "") != (a.TLSKeyFile == "") {
+		problems.add("tls_cert_file/TLS_CERT_FILE and tls_key_file/TLS_KEY_FILE: must be set together")
+	}
+
+	if a.HTTPPort != 0 {
+		if !a.IsHTTPSEnabled {
+			problems.add("http_port/HTTP_PORT=%d: requires enable_https/ENABLE_HTTPS", a.HTTPPort)
+		}
+		if a.HTTPPort < 0 || a.HTTPPort > 65535 || a.HTTPPort == a.ListenPort {
+			problems.add("http_port/HTTP_PORT=%d: must be between 1 and 65535 and differ from listen_port/LISTEN_PORT", a.HTTPPort)
+		}
+	}
+
+	if a.IsHTTPRedirected && a.HTTPPort == 0 {
+		problems.add("redirect_http/REDIRECT_HTTP: requires http_port/HTTP_PORT")
+	}
+
+	if a.DataDir == "" {
+		problems.add("data_dir/DATA_DIR: required")
+	}
+
+	if (a.AuthUsername == "") != (a.AuthPassword == "") {
+		problems.add("auth_username/AUTH_USERNAME and auth_password/AUTH_PASSWORD: must be set together")
+	}
+
+	if a.AuthToken != "" && a.AuthUsername == "" && len(a.Users) == 0 {
+		problems.add("auth_token/AUTH_TOKEN: requires auth_username/AUTH_USERNAME and auth_password/AUTH_PASSWORD, or users")
+	}
+
+	usernames := map[string]struct{}{a.AuthUsername: {}}
+	for i, u := range a.Users {
+		if u.Username == "" || u.Password == "" {
+			problems.add("users[%d]: username and password are required", i)
+		}
+		if _, found := usernames[u.Username]; found && u.Username != "" {
+			problems.add("users[%d]: username '%s' is already in use", i, u.Username)
+		}
+		usernames[u.Username] = struct{}{}
+	}
+
+	if a.PublicUrl != "" {
+		if u, err := url.Parse(a.PublicUrl); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" {
+			problems.add("public_url/PUBLIC_URL='%s': must be an absolute http(s) url without query, e.g. https://example.com/stash-vr", redact(a.PublicUrl, a.IsRedactDisabled))
+		}
+	}
+
+	if _, err := zerolog.ParseLevel(a.LogLevel); err != nil || a.LogLevel == "" {
+		problems.add("log_level/LOG_LEVEL='%s': must be one of trace, debug, info, warn, error", a.LogLevel)
+	}
+
+	if a.HeatmapHeightPx < 0 {
+		problems.add("heatmap_height_px/HEATMAP_HEIGHT_PX=%d: must not be negative", a.HeatmapHeightPx)
+	}
+
+	if a.MaxLinks < 0 {
+		problems.add("max_links/MAX_LINKS=%d: must not be negative", a.MaxLinks)
+	}
+	if a.SectionMaxScenes < 0 {
+		problems.add("section_max_scenes/SECTION_MAX_SCENES=%d: must not be negative", a.SectionMaxScenes)
+	}
+	if a.FolderDepth < 0 {
+		problems.add("folder_depth/FOLDER_DEPTH=%d: must not be negative", a.FolderDepth)
+	}
+	if a.StashPollIntervalSec < 0 {
+		problems.add("stash_poll_interval_sec/STASH_POLL_INTERVAL_SEC=%d: must not be negative", a.StashPollIntervalSec)
+	}
+	if filtersValidator != nil {
+		problems.Problems = append(problems.Problems, filtersValidator("filters/FILTERS", a.Filters)...)
+		for i, u := range a.Users {
+			problems.Problems = append(problems.Problems, filtersValidator(fmt.Sprintf("users[%d].filters", i), u.Filters)...)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		want         Application
+		wantProblems int
+	}{
+		{
+			name: "override defaults",
+			env:  map[string]string{"STASH_GRAPHQL_URL": "http://stash:9999/graphql", "ALLOW_SYNC_MARKERS": "true", "HEATMAP_HEIGHT_PX": "30"},
+			want: func() Application {
+				a := Default()
+				a.StashGraphQLUrl = "http://stash:9999/graphql"
+				a.IsSyncMarkersAllowed = true
+				a.HeatmapHeightPx = 30
+				return a
+			}(),
+		},
+		{
+			name:         "report all invalid values",
+			env:          map[string]string{"ALLOW_SYNC_MARKERS": "yes", "HEATMAP_HEIGHT_PX": "tall"},
+			want:         Default(),
+			wantProblems: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Default()
+			problems := &ValidationError{}
+			applyEnv(&got, func(key string) (string, bool) {
+				v, ok := tt.env[key]
+				return v, ok
+			}, problems)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyEnv() = %+v, want %+v", got, tt.want)
+			}
+			if len(problems.Problems) != tt.wantProblems {
+				t.Errorf("applyEnv() problems = %v, want %d", problems.Problems, tt.wantProblems)
+			}
+		})
+	}
+}
+
+func TestApplication_validate(t *testing.T) {
+	a := Default()
+	a.StashGraphQLUrl = "http:127.0.0.1:9999/graphql"
+	a.LogLevel = "verbose"
+	problems := &ValidationError{}
+	a.validate(problems)
+	if len(problems.Problems) != 2 {
+		t.Errorf("validate() problems = %v, want 2", problems.Problems)
+	}
+
+	SetFiltersValidator(func(key string, filters string) []string {
+		if filters == "bad" {
+			return []string{key + ": bad"}
+		}
+		return nil
+	})
+	defer SetFiltersValidator(nil)
+	a.Filters = "bad"
+	a.Users = []User{{Username: "a", Password: "b", Filters: "bad"}}
+	problems = &ValidationError{}
+	a.validate(problems)
+	if want := []string{"filters/FILTERS: bad", "users[0].filters: bad"}; len(problems.Problems) != 4 || !reflect.DeepEqual(problems.Problems[2:], want) {
+		t.Errorf("validate() problems = %v, want 2 and %v", problems.Problems, want)
+	}
+
+	problems = &ValidationError{}
+	Default().validate(problems)
+	if len(problems.Problems) != 0 {
+		t.Errorf("validate() on default = %v, want none", problems.Problems)
+	}
+}
+
+func TestApplication_validate_authToken(t *testing.T) {
+	tests := []struct {
+		name         string
+		a            func(a *Application)
+		wantProblems int
+	}{
+		{"with auth username", func(a *Application) { a.AuthUsername, a.AuthPassword = "a", "b" }, 0},
+		{"with users", func(a *Application) { a.Users = []User{{Username: "a", Password: "b"}} }, 0},
+		{"without login", func(a *Application) {}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Default()
+			a.AuthToken = "secret"
+			tt.a(&a)
+			problems := &ValidationError{}
+			a.validate(problems)
+			if len(problems.Problems) != tt.wantProblems {
+				t.Errorf("validate() problems = %v, want %d", problems.Problems, tt.wantProblems)
+			}
+		})
+	}
+}
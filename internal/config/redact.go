@@ -5,7 +5,11 @@ import (
 )
 
 func Redacted(s string) string {
-	if Get().IsRedactDisabled {
+	return redact(s, Get().IsRedactDisabled)
+}
+
+func redact(s string, isDisabled bool) string {
+	if isDisabled {
 		return s
 	}
 	return fmt.Sprintf("REDACTED(%d)", len(s))
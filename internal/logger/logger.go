@@ -13,7 +13,11 @@ func init() {
 		Out:        os.Stderr,
 		TimeFormat: "Jan 02, 15:04:05",
 	}).With().Str("mod", "default").Logger().Level(zerolog.TraceLevel) //.With().Caller().Logger()
+	zerolog.DefaultContextLogger = &log.Logger
+}
 
+// Init sets the log level of the configuration, which it loads.
+func Init() {
 	level, err := zerolog.ParseLevel(config.Get().LogLevel)
 	if err != nil {
 		panic(fmt.Sprintf("error parsing log level: %v", err))
@@ -0,0 +1,69 @@
+package profile
+
+import (
+	"context"
+	"stash-vr/internal/auth"
+	"stash-vr/internal/config"
+)
+
+// Profile holds the per-user view of the library. The default profile reads and writes Stash's global values.
+type Profile struct {
+	Username string
+	// FavoriteTag is the Stash tag marking this profile's favorites.
+	FavoriteTag string
+	// Filters overrides the global filters, empty to use them.
+	Filters string
+	// IsLocal keeps ratings and play counts in the local store instead of Stash.
+	IsLocal bool
+	// IsStashSyncEnabled writes ratings and play counts to Stash as well when IsLocal.
+	IsStashSyncEnabled bool
+}
+
+// WritesToStash reports whether ratings and play counts should be sent to Stash.
+func (p Profile) WritesToStash() bool {
+	return !p.IsLocal || p.IsStashSyncEnabled
+}
+
+func Default() Profile {
+	return Profile{
+		FavoriteTag: config.Get().FavoriteTag,
+	}
+}
+
+// FromContext returns the profile of the user authenticated in ctx, the default profile otherwise.
+func FromContext(ctx context.Context) Profile {
+	username := auth.Username(ctx)
+	for _, u := range config.Get().Users {
+		if u.Username == username {
+			return fromUser(u)
+		}
+	}
+	return Default()
+}
+
+func fromUser(u config.User) Profile {
+	favoriteTag := u.FavoriteTag
+	if favoriteTag == "" {
+		favoriteTag = config.Get().FavoriteTag + " (" + u.Username + ")"
+	}
+	return Profile{
+		Username:           u.Username,
+		FavoriteTag:        favoriteTag,
+		Filters:            u.Filters,
+		IsLocal:            true,
+		IsStashSyncEnabled: u.IsStashSyncEnabled,
+	}
+}
+
+// IsFavoriteTag reports whether name is the favorite tag of any profile, such tags are not shown as regular tags.
+func IsFavoriteTag(name string) bool {
+	if name == config.Get().FavoriteTag {
+		return true
+	}
+	for _, u := range config.Get().Users {
+		if fromUser(u).FavoriteTag == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"stash-vr/internal/config"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const storeFileName = "profiles.json"
+
+// SceneState is the per-user data kept for a scene in the local store.
+type SceneState struct {
+	Rating100    int       `json:"rating100,omitempty"`
+	PlayCount    int       `json:"play_count,omitempty"`
+	LastPlayedAt time.Time `json:"last_played_at,omitempty"`
+}
+
+type store struct {
+	mu     sync.Mutex
+	loaded bool
+	Users  map[string]map[string]SceneState `json:"users"`
+}
+
+var localStore store
+
+func storePath() string {
+	return filepath.Join(config.Get().DataDir, storeFileName)
+}
+
+// load must be called with mu held.
+func (st *store) load() {
+	if st.loaded {
+		return
+	}
+	st.loaded = true
+	st.Users = make(map[string]map[string]SceneState)
+
+	b, err := os.ReadFile(storePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("path", storePath()).Msg("Failed to read profile store")
+		return
+	}
+	if err := json.Unmarshal(b, st); err != nil {
+		log.Warn().Err(err).Str("path", storePath()).Msg("Failed to parse profile store")
+	}
+	if st.Users == nil {
+		st.Users = make(map[string]map[string]SceneState)
+	}
+}
+
+// save must be called with mu held.
+func (st *store) save() error {
+	b, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	if err := os.MkdirAll(config.Get().DataDir, 0700); err != nil {
+		return fmt.Errorf("create dir: %w", err)
+	}
+	tmp := storePath() + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	if err := os.Rename(tmp, storePath()); err != nil {
+		return fmt.Errorf("rename: %w", err)
+	}
+	return nil
+}
+
+func (st *store) update(username string, sceneId string, f func(state *SceneState)) (SceneState, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.load()
+
+	scenes, ok := st.Users[username]
+	if !ok {
+		scenes = make(map[string]SceneState)
+		st.Users[username] = scenes
+	}
+	state := scenes[sceneId]
+	f(&state)
+	scenes[sceneId] = state
+
+	return state, st.save()
+}
+
+// Scene returns the stored state of a scene for the profile.
+func (p Profile) Scene(sceneId string) SceneState {
+	localStore.mu.Lock()
+	defer localStore.mu.Unlock()
+	localStore.load()
+	return localStore.Users[p.Username][sceneId]
+}
+
+// Scenes returns the stored state of all scenes for the profile, keyed by scene id.
+func (p Profile) Scenes() map[string]SceneState {
+	localStore.mu.Lock()
+	defer localStore.mu.Unlock()
+	localStore.load()
+	scenes := make(map[string]SceneState, len(localStore.Users[p.Username]))
+	for id, state := range localStore.Users[p.Username] {
+		scenes[id] = state
+	}
+	return scenes
+}
+
+func (p Profile) SetRating(sceneId string, rating100 int) error {
+	_, err := localStore.update(p.Username, sceneId, func(state *SceneState) {
+		state.Rating100 = rating100
+	})
+	return err
+}
+
+func (p Profile) IncrementPlayCount(sceneId string) (int, error) {
+	state, err := localStore.update(p.Username, sceneId, func(state *SceneState) {
+		state.PlayCount++
+		state.LastPlayedAt = time.Now()
+	})
+	return state.PlayCount, err
+}
@@ -0,0 +1,120 @@
+// Package recommend ranks scenes by similarity of their tags, performers and studio.
+package recommend
+
+import (
+	"math"
+	"sort"
+)
+
+// Feature weights relative to a tag. A shared performer says more about a scene than a shared tag, a shared studio less.
+const (
+	tagWeight       = 1
+	performerWeight = 2
+	studioWeight    = 0.5
+)
+
+// Item is a scene as seen by the recommender.
+type Item struct {
+	Id         string
+	Tags       []string
+	Performers []string
+	Studio     string
+}
+
+// Scored is a recommended scene with its score, the mean similarity to the seeds.
+type Scored struct {
+	Id    string
+	Score float64
+}
+
+// Index holds the weighted features of a set of scenes.
+type Index struct {
+	features map[string]map[string]float64
+}
+
+// New indexes items, weighting every feature by its inverse document frequency so that rare tags count more than common ones.
+func New(items []Item) *Index {
+	raw := make(map[string]map[string]float64, len(items))
+	documentFrequency := make(map[string]int)
+	for _, item := range items {
+		f := make(map[string]float64)
+		for _, t := range item.Tags {
+			f["t:"+t] = tagWeight
+		}
+		for _, p := range item.Performers {
+			f["p:"+p] = performerWeight
+		}
+		if item.Studio != "" {
+			f["s:"+item.Studio] = studioWeight
+		}
+		for k := range f {
+			documentFrequency[k]++
+		}
+		raw[item.Id] = f
+	}
+
+	n := float64(len(items))
+	for _, f := range raw {
+		for k, w := range f {
+			f[k] = w * math.Log(1+n/float64(documentFrequency[k]))
+		}
+	}
+	return &Index{features: raw}
+}
+
+// Similarity is the weighted Jaccard index of the features of two scenes, 0 if either is unknown.
+func (idx *Index) Similarity(a, b string) float64 {
+	fa, fb := idx.features[a], idx.features[b]
+	var intersection, union float64
+	for k, w := range fa {
+		if _, ok := fb[k]; ok {
+			intersection += w
+		}
+		union += w
+	}
+	for k, w := range fb {
+		if _, ok := fa[k]; !ok {
+			union += w
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return intersection / union
+}
+
+// Recommend returns up to limit scenes most similar to the seeds, excluding the seeds themselves and
+// scenes sharing nothing with them. Ties are broken by id to keep the result stable.
+func (idx *Index) Recommend(seeds []string, limit int) []Scored {
+	if len(seeds) == 0 {
+		return nil
+	}
+	isSeed := make(map[string]bool, len(seeds))
+	for _, s := range seeds {
+		isSeed[s] = true
+	}
+
+	var scored []Scored
+	for id := range idx.features {
+		if isSeed[id] {
+			continue
+		}
+		var sum float64
+		for _, s := range seeds {
+			sum += idx.Similarity(s, id)
+		}
+		if sum > 0 {
+			scored = append(scored, Scored{Id: id, Score: sum / float64(len(seeds))})
+		}
+	}
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return scored[i].Id < scored[j].Id
+	})
+	if limit > 0 && len(scored) > limit {
+		scored = scored[:limit]
+	}
+	return scored
+}
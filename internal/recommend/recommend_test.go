@@ -0,0 +1,56 @@
+package recommend
+
+import (
+	"reflect"
+	"testing"
+)
+
+var items = []Item{
+	{Id: "1", Tags: []string{"pov", "outdoor"}, Performers: []string{"alice"}, Studio: "a"},
+	{Id: "2", Tags: []string{"pov", "outdoor"}, Performers: []string{"alice"}, Studio: "a"},
+	{Id: "3", Tags: []string{"pov", "beach"}, Performers: []string{"bob"}, Studio: "a"},
+	{Id: "4", Tags: []string{"pov"}, Performers: []string{"bob"}, Studio: "b"},
+	{Id: "5", Tags: []string{"indoor"}, Studio: "b"},
+}
+
+func TestIndex_Similarity(t *testing.T) {
+	idx := New(items)
+	if got := idx.Similarity("1", "2"); got != 1 {
+		t.Errorf("Similarity() of identical features = %v, want 1", got)
+	}
+	if got := idx.Similarity("1", "5"); got != 0 {
+		t.Errorf("Similarity() of disjoint features = %v, want 0", got)
+	}
+	if got := idx.Similarity("1", "unknown"); got != 0 {
+		t.Errorf("Similarity() of unknown scene = %v, want 0", got)
+	}
+	if idx.Similarity("1", "3") <= idx.Similarity("1", "4") {
+		t.Errorf("Similarity() sharing a studio and a common tag should be higher than sharing only the common tag")
+	}
+}
+
+func TestIndex_Recommend(t *testing.T) {
+	idx := New(items)
+	tests := []struct {
+		name  string
+		seeds []string
+		limit int
+		want  []string
+	}{
+		{"most similar first", []string{"1"}, 0, []string{"2", "3", "4"}},
+		{"limit", []string{"1"}, 1, []string{"2"}},
+		{"seeds excluded", []string{"1", "2"}, 0, []string{"3", "4"}},
+		{"no seeds", nil, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, s := range idx.Recommend(tt.seeds, tt.limit) {
+				got = append(got, s.Id)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Recommend() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
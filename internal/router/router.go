@@ -6,10 +6,12 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/rs/zerolog/log"
 	"net/http"
+	"stash-vr/internal/api/admin"
 	"stash-vr/internal/api/deovr"
 	"stash-vr/internal/api/heatmap"
 	"stash-vr/internal/api/heresphere"
 	"stash-vr/internal/api/web"
+	"stash-vr/internal/auth"
 	"stash-vr/internal/config"
 	"stash-vr/internal/util"
 	"strings"
@@ -27,6 +29,7 @@ func Build(client graphql.Client) *chi.Mux {
 
 	router.Mount("/heresphere", logMod("heresphere", heresphere.Router(client)))
 	router.Mount("/deovr", logMod("deovr", deovr.Router(client)))
+	router.Mount("/api", logMod("admin", auth.RequireWeb(admin.Router(client))))
 
 	router.Get("/", rootHandler(client))
 	router.Get("/*", logMod("static", staticHandler()).ServeHTTP)
@@ -44,9 +47,9 @@ func rootHandler(client graphql.Client) http.HandlerFunc {
 
 		if strings.Contains(userAgent, "HereSphere") {
 			log.Ctx(r.Context()).Trace().Msg("Redirecting to /heresphere")
-			http.Redirect(w, r, "/heresphere", 307)
+			http.Redirect(w, r, util.GetBaseUrl(r)+"/heresphere", 307)
 		} else {
-			logMod("web", web.IndexHandler(client)).ServeHTTP(w, r)
+			logMod("web", auth.RequireWeb(web.IndexHandler(client))).ServeHTTP(w, r)
 		}
 	}
 }
@@ -0,0 +1,66 @@
+package sections
+
+import (
+	"context"
+	"stash-vr/internal/cache"
+	"stash-vr/internal/sections/diagnostic"
+	"stash-vr/internal/sections/internal"
+	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash/gql"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// ErrFilterNotFound is returned by AdHoc for a saved filter name that doesn't exist.
+var ErrFilterNotFound = internal.ErrFilterNotFound
+
+// AdHocRequest selects the scenes of a one-off library, by Expr or by FilterName.
+type AdHocRequest struct {
+	// Expr is the name of a saved filter, or a query if no saved filter has that name.
+	Expr string
+	// FilterName is the name of a saved filter.
+	FilterName string
+}
+
+// adHocDiagnosticBuildPrefix prefixes the builds of ad-hoc libraries in the filter diagnostics.
+const adHocDiagnosticBuildPrefix = "ad-hoc "
+
+// diagnosticBuild names the build of the library in the filter diagnostics.
+func (r AdHocRequest) diagnosticBuild() string {
+	if r.FilterName != "" {
+		return adHocDiagnosticBuildPrefix + "filter=" + r.FilterName
+	}
+	return adHocDiagnosticBuildPrefix + r.Expr
+}
+
+// adHocCache keeps recently requested one-off libraries, bookmarks tend to be opened repeatedly.
+var adHocCache = cache.New[AdHocRequest, []section.Section]("adhoc", cache.Options{TTL: ttl, MaxEntries: 32})
+
+// adHocScansCache keeps the scan data of recently requested one-off libraries, keyed like adHocCache.
+var adHocScansCache = cache.New[AdHocRequest, []*gql.SceneScanParts]("adhocScans", cache.Options{TTL: ttl, MaxEntries: 32})
+
+// AdHoc returns the sections of a one-off library, capped like the configured sections.
+// It leaves the configured sections as they are.
+func AdHoc(ctx context.Context, client graphql.Client, r AdHocRequest) ([]section.Section, error) {
+	return adHocCache.Get(ctx, r, func(ctx context.Context) ([]section.Section, error) {
+		build := r.diagnosticBuild()
+		diagnostic.Reset(build)
+		ctx = diagnostic.WithBuild(ctx, build)
+		ss, err := internal.SectionsByAdHoc(ctx, client, r.Expr, r.FilterName)
+		if err != nil {
+			return nil, err
+		}
+		return capsFromConfig().apply(ctx, ss), nil
+	})
+}
+
+// AdHocScans returns the scan data of the scenes in a one-off library, which needn't be in any configured section.
+func AdHocScans(ctx context.Context, client graphql.Client, r AdHocRequest) ([]*gql.SceneScanParts, error) {
+	return adHocScansCache.Get(ctx, r, func(ctx context.Context) ([]*gql.SceneScanParts, error) {
+		ss, err := AdHoc(ctx, client, r)
+		if err != nil {
+			return nil, err
+		}
+		return scansOfSections(ctx, client, ss)
+	})
+}
@@ -0,0 +1,184 @@
+package sections
+
+import (
+	"context"
+	"fmt"
+	"stash-vr/internal/config"
+	"stash-vr/internal/sections/definition"
+	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash/gql"
+
+	"github.com/rs/zerolog/log"
+)
+
+// caps keeps the number of links within what players can handle.
+type caps struct {
+	// maxLinks caps the links of all sections together, 0 is unlimited.
+	maxLinks int
+	// sectionMaxScenes caps the scenes of a section, 0 is unlimited.
+	sectionMaxScenes int
+	// split splits sections over sectionMaxScenes into pages instead of truncating them.
+	split bool
+	// dedupe drops scenes already shown in an earlier section.
+	dedupe bool
+}
+
+func capsFromConfig() caps {
+	return caps{
+		maxLinks:         config.Get().MaxLinks,
+		sectionMaxScenes: config.Get().SectionMaxScenes,
+		split:            config.Get().IsSplitSections,
+		dedupe:           config.Get().IsDedupeScenes,
+	}
+}
+
+// apply splits or truncates each section, then drops duplicate scenes, then caps the links of all sections. Scenes are
+// deduped by what's left of a truncated section so they're still shown in a later one, and before the link cap so
+// duplicates don't take up links.
+func (c caps) apply(ctx context.Context, sections []section.Section) []section.Section {
+	var paged []section.Section
+	for _, s := range sections {
+		paged = append(paged, c.paginate(s)...)
+	}
+
+	if c.dedupe {
+		paged = dedupeScenes(paged)
+	}
+
+	if c.maxLinks == 0 {
+		return paged
+	}
+	capped := make([]section.Section, 0, len(paged))
+	remaining := c.maxLinks
+	for i, s := range paged {
+		if remaining == 0 {
+			log.Ctx(ctx).Info().Int("maxLinks", c.maxLinks).Int("dropped", len(paged)-i).Msg("Link limit reached, dropping remaining sections")
+			break
+		}
+		if s.Len() > remaining {
+			log.Ctx(ctx).Info().Int("maxLinks", c.maxLinks).Str("section", s.Name).Int("scenes", remaining).Msg("Link limit reached, truncating section")
+			s = s.Slice(0, remaining)
+		}
+		remaining -= s.Len()
+		capped = append(capped, s)
+	}
+	return capped
+}
+
+// paginate splits s into pages named "Name (1/3)" if it's to be split, otherwise truncates it.
+func (c caps) paginate(s section.Section) []section.Section {
+	pageSize, split := s.PageSize, s.PageSize > 0
+	if !split {
+		pageSize, split = c.sectionMaxScenes, c.split
+	}
+	if pageSize == 0 || s.Len() <= pageSize {
+		return []section.Section{s}
+	}
+	if !split {
+		return []section.Section{s.Slice(0, pageSize)}
+	}
+
+	pageCount := (s.Len() + pageSize - 1) / pageSize
+	pages := make([]section.Section, pageCount)
+	for i := range pages {
+		end := (i + 1) * pageSize
+		if end > s.Len() {
+			end = s.Len()
+		}
+		page := s.Slice(i*pageSize, end)
+		page.Name = fmt.Sprintf("%s (%d/%d)", s.Name, i+1, pageCount)
+		pages[i] = page
+	}
+	return pages
+}
+
+// dedupeScenes drops scenes shown in an earlier section of the same player, and sections left empty. Each player is
+// deduped by the sections it shows, a section of all players that ends up different per player is replaced by a copy
+// per player. Marker sections are kept as is, a marker is a clip rather than the scene.
+func dedupeScenes(sections []section.Section) []section.Section {
+	if !hasPlayerSections(sections) {
+		return dedupeOrdered(dedupePlayer("", sections), len(sections))
+	}
+
+	heresphere := dedupePlayer(definition.PlayerHereSphere, sections)
+	deovr := dedupePlayer(definition.PlayerDeoVR, sections)
+	deduped := make([]section.Section, 0, len(sections))
+	for i, s := range sections {
+		h, isHereSphere := heresphere[i]
+		d, isDeoVR := deovr[i]
+		if s.Player == "" && isHereSphere && isDeoVR && sameScenes(h, d) {
+			deduped = append(deduped, h)
+			continue
+		}
+		if isHereSphere {
+			h.Player = definition.PlayerHereSphere
+			deduped = append(deduped, h)
+		}
+		if isDeoVR {
+			d.Player = definition.PlayerDeoVR
+			deduped = append(deduped, d)
+		}
+	}
+	return deduped
+}
+
+func hasPlayerSections(sections []section.Section) bool {
+	for _, s := range sections {
+		if s.Player != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// dedupePlayer dedupes the sections shown in player, or all sections if empty. They're keyed by their index in
+// sections, sections not shown in player or left empty are left out.
+func dedupePlayer(player string, sections []section.Section) map[int]section.Section {
+	seen := make(map[string]bool)
+	deduped := make(map[int]section.Section, len(sections))
+	for i, s := range sections {
+		if player != "" && s.Player != "" && s.Player != player {
+			continue
+		}
+		if len(s.Markers) > 0 {
+			deduped[i] = s
+			continue
+		}
+		list := make([]gql.ScenePreviewParts, 0, len(s.PreviewPartsList))
+		for _, p := range s.PreviewPartsList {
+			if !seen[p.Id] {
+				list = append(list, p)
+				seen[p.Id] = true
+			}
+		}
+		if len(list) == 0 {
+			continue
+		}
+		s.PreviewPartsList = list
+		deduped[i] = s
+	}
+	return deduped
+}
+
+func dedupeOrdered(deduped map[int]section.Section, n int) []section.Section {
+	ordered := make([]section.Section, 0, len(deduped))
+	for i := 0; i < n; i++ {
+		if s, ok := deduped[i]; ok {
+			ordered = append(ordered, s)
+		}
+	}
+	return ordered
+}
+
+// sameScenes reports whether a and b list the same scenes, markers are compared by count as they're kept as is.
+func sameScenes(a section.Section, b section.Section) bool {
+	if len(a.PreviewPartsList) != len(b.PreviewPartsList) || len(a.Markers) != len(b.Markers) {
+		return false
+	}
+	for i := range a.PreviewPartsList {
+		if a.PreviewPartsList[i].Id != b.PreviewPartsList[i].Id {
+			return false
+		}
+	}
+	return true
+}
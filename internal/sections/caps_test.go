@@ -0,0 +1,75 @@
+package sections
+
+import (
+	"context"
+	"reflect"
+	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash/gql"
+	"strings"
+	"testing"
+)
+
+// testSection returns a section with a scene per character of ids.
+func testSection(name string, ids string) section.Section {
+	s := section.Section{Name: name}
+	for _, id := range ids {
+		s.PreviewPartsList = append(s.PreviewPartsList, gql.ScenePreviewParts{Id: string(id)})
+	}
+	return s
+}
+
+// testMarkerSection returns a section with a marker of scene 1 per character of ids.
+func testMarkerSection(name string, ids string) section.Section {
+	s := section.Section{Name: name}
+	for _, id := range ids {
+		s.Markers = append(s.Markers, section.Marker{Id: string(id), Scene: gql.ScenePreviewParts{Id: "1"}})
+	}
+	return s
+}
+
+func TestCaps_apply(t *testing.T) {
+	paged := testSection("paged", "12345")
+	paged.PageSize = 2
+	deovr := testSection("d", "12")
+	deovr.Player = "deovr"
+
+	tests := []struct {
+		name     string
+		caps     caps
+		sections []section.Section
+		want     []string
+	}{
+		{"unlimited", caps{}, []section.Section{testSection("a", "123"), testSection("b", "13")}, []string{"a:123", "b:13"}},
+		{"truncate", caps{sectionMaxScenes: 2}, []section.Section{testSection("a", "123")}, []string{"a:12"}},
+		{"split", caps{sectionMaxScenes: 2, split: true}, []section.Section{testSection("a", "12345"), testSection("b", "12")}, []string{"a (1/3):12", "a (2/3):34", "a (3/3):5", "b:12"}},
+		{"split by section", caps{sectionMaxScenes: 1}, []section.Section{paged}, []string{"paged (1/3):12", "paged (2/3):34", "paged (3/3):5"}},
+		{"max links", caps{maxLinks: 4}, []section.Section{testSection("a", "123"), testSection("b", "456"), testSection("c", "7")}, []string{"a:123", "b:4"}},
+		{"dedupe", caps{dedupe: true}, []section.Section{testSection("a", "123"), testSection("b", "13"), testSection("c", "34"), deovr}, []string{"a:123", "c:4"}},
+		{"markers", caps{sectionMaxScenes: 2, split: true, dedupe: true}, []section.Section{testSection("a", "12"), testMarkerSection("m", "789")}, []string{"a:12", "m (1/2):78", "m (2/2):9"}},
+		{"dedupe truncated", caps{sectionMaxScenes: 2, dedupe: true}, []section.Section{testSection("a", "123"), testSection("b", "34")}, []string{"a:12", "b:34"}},
+		{"dedupe before max links", caps{maxLinks: 4, dedupe: true}, []section.Section{testSection("a", "123"), testSection("b", "34"), testSection("c", "5")}, []string{"a:123", "b:4"}},
+		{"dedupe by player", caps{dedupe: true}, []section.Section{deovr, testSection("a", "123"), testSection("b", "45")}, []string{"deovr/d:12", "heresphere/a:123", "deovr/a:3", "b:45"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, s := range tt.caps.apply(context.Background(), tt.sections) {
+				var ids strings.Builder
+				for _, p := range s.PreviewPartsList {
+					ids.WriteString(p.Id)
+				}
+				for _, m := range s.Markers {
+					ids.WriteString(m.Id)
+				}
+				name := s.Name
+				if s.Player != "" {
+					name = s.Player + "/" + name
+				}
+				got = append(got, name+":"+ids.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+// Package definition reads the sections file describing which sections to build and how.
+package definition
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"stash-vr/internal/stash/filter/query"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	PlayerHereSphere = "heresphere"
+	PlayerDeoVR      = "deovr"
+)
+
+type File struct {
+	Sections []Definition `yaml:"sections"`
+}
+
+// Definition is one entry of the sections file. Exactly one of FilterId, FilterName, Source and Query selects the
+// scenes.
+type Definition struct {
+	FilterId   string `yaml:"filter_id"`
+	FilterName string `yaml:"filter_name"`
+	// Source is a source as in FILTERS, e.g. frontpage, that may produce several sections.
+	Source string `yaml:"source"`
+	// Query is an ad-hoc filter, e.g. tags:"POV" AND rating>=80 sort:date desc.
+	Query string `yaml:"query"`
+
+	// Name overrides the name of the saved filter.
+	Name string `yaml:"name"`
+	// Group is prepended to the name of every section of the entry.
+	Group string `yaml:"group"`
+	// Limit caps the number of scenes, 0 is unlimited. With Split it's the number of scenes per page instead.
+	Limit     int    `yaml:"limit"`
+	Split     bool   `yaml:"split"`
+	Sort      string `yaml:"sort"`
+	Direction string `yaml:"direction"`
+	// Player restricts the sections to one player, empty for all.
+	Player string `yaml:"player"`
+
+	// Auto configures the generated sections of the tags, studios, performers and marker_tags sources.
+	Auto Auto `yaml:"auto"`
+	// Discover configures the discover source.
+	Discover Discover `yaml:"discover"`
+	// Folders configures the folders source.
+	Folders Folders `yaml:"folders"`
+}
+
+// Folders overrides FOLDER_ROOTS and FOLDER_DEPTH for the folders source.
+type Folders struct {
+	Roots []string `yaml:"roots"`
+	// Depth is the number of folder levels below a root that get a section, 0 is unlimited. Nil for FOLDER_DEPTH.
+	Depth *int `yaml:"depth"`
+}
+
+const (
+	DiscoverWeightUnwatched = "unwatched"
+	DiscoverWeightRated     = "rated"
+)
+
+// Discover configures the sampling of the discover section.
+type Discover struct {
+	// Weight favours unwatched (DiscoverWeightUnwatched) or highly rated (DiscoverWeightRated) scenes, empty for none.
+	Weight string `yaml:"weight"`
+}
+
+// Auto selects and orders the sections generated per tag, studio or favourite performer.
+type Auto struct {
+	// MinScenes skips tags, studios and performers with fewer scenes, or marker tags with fewer markers.
+	MinScenes int `yaml:"min_scenes"`
+	// Top keeps only the first sections after ordering, 0 keeps all.
+	Top int `yaml:"top"`
+	// Order is AutoOrderSceneCount (default) or AutoOrderName.
+	Order   string   `yaml:"order"`
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+	// Parent only generates sections for direct children of the tag with this name. Tags and marker tags only.
+	Parent string `yaml:"parent"`
+}
+
+const (
+	AutoOrderSceneCount = "scene_count"
+	AutoOrderName       = "name"
+)
+
+// IsAutoSource reports whether source generates a section per tag, studio, performer or marker tag.
+func IsAutoSource(source string) bool {
+	return source == SourceTags || source == SourceStudios || source == SourcePerformers || source == SourceMarkerTags
+}
+
+const (
+	SourceFrontpage        = "frontpage"
+	SourceAll              = "all"
+	SourceTags             = "tags"
+	SourceStudios          = "studios"
+	SourcePerformers       = "performers"
+	SourceContinueWatching = "continue_watching"
+	SourceRecentlyPlayed   = "recently_played"
+	SourceMostPlayed       = "most_played"
+	SourceDiscover         = "discover"
+	SourceRecommended      = "recommended"
+	// SourceMarkerTags generates a section of markers per primary tag of scene markers.
+	SourceMarkerTags = "marker_tags"
+	// SourceFolders generates a section per folder below the folder roots.
+	SourceFolders = "folders"
+)
+
+// Sources lists the sources that can be used in FILTERS and the sections file.
+var Sources = []string{SourceFrontpage, SourceAll, SourceTags, SourceStudios, SourcePerformers,
+	SourceContinueWatching, SourceRecentlyPlayed, SourceMostPlayed, SourceDiscover, SourceRecommended, SourceMarkerTags, SourceFolders}
+
+// IsPlayHistorySource reports whether source is built from Stash's play history.
+func IsPlayHistorySource(source string) bool {
+	return source == SourceContinueWatching || source == SourceRecentlyPlayed || source == SourceMostPlayed
+}
+
+// isSingleSectionSource reports whether source always produces at most one section, allowing its name to be set.
+func isSingleSectionSource(source string) bool {
+	return IsPlayHistorySource(source) || source == SourceDiscover || source == SourceRecommended
+}
+
+// Errors holds every problem found in the sections file.
+type Errors struct {
+	Problems []string
+}
+
+func (e *Errors) Error() string {
+	return fmt.Sprintf("%d sections file problem(s): %s", len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+func (e *Errors) add(format string, a ...any) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, a...))
+}
+
+// Load reads and validates the sections file at path. It returns os.ErrNotExist if there is none
+// and an *Errors listing all problems if it's invalid.
+func Load(path string) ([]Definition, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f File
+	if err := yaml.UnmarshalStrict(b, &f); err != nil {
+		return nil, &Errors{Problems: []string{fmt.Sprintf("parse %s: %v", path, err)}}
+	}
+
+	problems := &Errors{}
+	if len(f.Sections) == 0 {
+		problems.add("%s: no sections defined", path)
+	}
+	for i := range f.Sections {
+		f.Sections[i].normalize()
+		f.Sections[i].validate(fmt.Sprintf("sections[%d]", i), problems)
+	}
+	if len(problems.Problems) > 0 {
+		return nil, problems
+	}
+	return f.Sections, nil
+}
+
+// IsNotExist reports whether err is returned by Load for a missing file.
+func IsNotExist(err error) bool {
+	return errors.Is(err, os.ErrNotExist)
+}
+
+func (d *Definition) normalize() {
+	d.Source = strings.ToLower(strings.TrimSpace(d.Source))
+	d.Player = strings.ToLower(d.Player)
+	d.Direction = strings.ToUpper(d.Direction)
+	d.Auto.Order = strings.ToLower(d.Auto.Order)
+	d.Discover.Weight = strings.ToLower(d.Discover.Weight)
+}
+
+func (d *Definition) validate(key string, problems *Errors) {
+	selectors := 0
+	for _, s := range []string{d.FilterId, d.FilterName, d.Source, d.Query} {
+		if s != "" {
+			selectors++
+		}
+	}
+	if selectors != 1 {
+		problems.add("%s: exactly one of filter_id, filter_name, source and query must be set", key)
+	}
+	if d.Query != "" {
+		if _, err := query.Parse(d.Query); err != nil {
+			problems.add("%s: query: %v", key, err)
+		}
+	}
+	if d.FilterId != "" {
+		if _, err := strconv.Atoi(d.FilterId); err != nil {
+			problems.add("%s: filter_id '%s' is not a number", key, d.FilterId)
+		}
+	}
+	if d.Source != "" {
+		if !IsSource(d.Source) {
+			problems.add("%s: unknown source '%s', must be one of %s", key, d.Source, strings.Join(Sources, ", "))
+		}
+		if d.Name != "" && !isSingleSectionSource(d.Source) {
+			problems.add("%s: name can't be set for source '%s', it may produce several sections", key, d.Source)
+		}
+		if !IsAutoSource(d.Source) && (d.Sort != "" || d.Direction != "") {
+			problems.add("%s: sort and direction can't be set for source '%s'", key, d.Source)
+		}
+	}
+	if !IsAutoSource(d.Source) && !reflect.DeepEqual(d.Auto, Auto{}) {
+		problems.add("%s: auto can only be set for sources %s, %s, %s and %s", key, SourceTags, SourceStudios, SourcePerformers, SourceMarkerTags)
+	}
+	if d.Auto.MinScenes < 0 || d.Auto.Top < 0 {
+		problems.add("%s: auto.min_scenes and auto.top must not be negative", key)
+	}
+	if d.Auto.Order != "" && d.Auto.Order != AutoOrderSceneCount && d.Auto.Order != AutoOrderName {
+		problems.add("%s: auto.order '%s' must be %s or %s", key, d.Auto.Order, AutoOrderSceneCount, AutoOrderName)
+	}
+	if d.Auto.Parent != "" && d.Source != SourceTags && d.Source != SourceMarkerTags {
+		problems.add("%s: auto.parent can only be set for sources %s and %s", key, SourceTags, SourceMarkerTags)
+	}
+	if d.Discover != (Discover{}) && d.Source != SourceDiscover {
+		problems.add("%s: discover can only be set for source %s", key, SourceDiscover)
+	}
+	if d.Discover.Weight != "" && d.Discover.Weight != DiscoverWeightUnwatched && d.Discover.Weight != DiscoverWeightRated {
+		problems.add("%s: discover.weight '%s' must be %s or %s", key, d.Discover.Weight, DiscoverWeightUnwatched, DiscoverWeightRated)
+	}
+	if (len(d.Folders.Roots) > 0 || d.Folders.Depth != nil) && d.Source != SourceFolders {
+		problems.add("%s: folders can only be set for source %s", key, SourceFolders)
+	}
+	if d.Folders.Depth != nil && *d.Folders.Depth < 0 {
+		problems.add("%s: folders.depth must not be negative", key)
+	}
+	if d.Limit < 0 {
+		problems.add("%s: limit must not be negative", key)
+	}
+	if d.Split && d.Limit == 0 {
+		problems.add("%s: split requires limit, the number of scenes per page", key)
+	}
+	if d.Direction != "" && d.Direction != "ASC" && d.Direction != "DESC" {
+		problems.add("%s: direction '%s' must be asc or desc", key, d.Direction)
+	}
+	if d.Player != "" && d.Player != PlayerHereSphere && d.Player != PlayerDeoVR {
+		problems.add("%s: player '%s' must be %s or %s", key, d.Player, PlayerHereSphere, PlayerDeoVR)
+	}
+}
+
+// IsSource reports whether s is one of Sources.
+func IsSource(s string) bool {
+	return contains(Sources, s)
+}
+
+// ValidateFilters returns the problems of a comma separated FILTERS list, entries must be sources or saved filter ids.
+func ValidateFilters(key string, filters string) []string {
+	problems := &Errors{}
+	for _, source := range strings.Split(filters, ",") {
+		source = strings.ToLower(strings.TrimSpace(source))
+		if source == "" || IsSource(source) {
+			continue
+		}
+		if _, err := strconv.Atoi(source); err != nil {
+			problems.add("%s: '%s' is not a filter id. Must be a comma separated list of filter ids and %s", key, source, strings.Join(Sources, ", "))
+		}
+	}
+	return problems.Problems
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package definition
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantProblems int
+	}{
+		{"valid", "sections:\n  - source: Frontpage\n  - filter_id: 12\n    name: POV\n    limit: 50\n    direction: desc\n    player: HereSphere\n", 0},
+		{"empty", "sections: []\n", 1},
+		{"unknown field", "sections:\n  - filter: 1\n", 1},
+		{"no selector", "sections:\n  - name: POV\n", 1},
+		{"invalid values", "sections:\n  - filter_id: x\n    limit: -1\n    direction: up\n    player: quest\n", 4},
+		{"split", "sections:\n  - filter_id: 1\n    limit: 100\n    split: true\n  - source: all\n    split: true\n", 1},
+		{"source with name", "sections:\n  - source: all\n    name: All\n", 1},
+		{"play history", "sections:\n  - source: continue_watching\n    name: Resume\n    limit: 20\n  - source: most_played\n    sort: date\n", 1},
+		{"discover", "sections:\n  - source: discover\n    name: Today\n    limit: 30\n    discover: {weight: Unwatched}\n  - filter_id: 1\n    discover: {weight: popular}\n", 2},
+		{"auto", "sections:\n  - source: tags\n    sort: date\n    auto: {min_scenes: 5, top: 10, order: Name, parent: Category}\n", 0},
+		{"invalid auto", "sections:\n  - source: studios\n    auto: {top: -1, order: count, parent: Category}\n  - filter_id: 1\n    auto: {top: 1}\n", 4},
+		{"marker tags", "sections:\n  - source: marker_tags\n    limit: 20\n    auto: {min_scenes: 3, parent: Positions}\n", 0},
+		{"query", "sections:\n  - query: 'tags:\"POV\" AND rating>=80 sort:date desc'\n    name: POV\n  - query: 'tags:(POV'\n  - query: rating>80\n    source: all\n", 2},
+		{"folders", "sections:\n  - source: folders\n    folders: {roots: [/media, 'D:\\Media'], depth: 2}\n  - source: all\n    folders: {depth: -1}\n", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "sections.yml")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Load(path)
+			var problems *Errors
+			if errors.As(err, &problems) {
+				if len(problems.Problems) != tt.wantProblems {
+					t.Errorf("Load() problems = %v, want %d", problems.Problems, tt.wantProblems)
+				}
+			} else if err != nil || tt.wantProblems != 0 {
+				t.Errorf("Load() error = %v, want %d problems", err, tt.wantProblems)
+			}
+		})
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yml")); !IsNotExist(err) {
+		t.Errorf("Load() of missing file error = %v, want not exist", err)
+	}
+}
+
+func TestValidateFilters(t *testing.T) {
+	if problems := ValidateFilters("filters", "1, Frontpage,all,,12"); len(problems) != 0 {
+		t.Errorf("ValidateFilters() = %v, want none", problems)
+	}
+	if problems := ValidateFilters("filters", "1,newest,all,pov"); len(problems) != 2 {
+		t.Errorf("ValidateFilters() = %v, want 2", problems)
+	}
+}
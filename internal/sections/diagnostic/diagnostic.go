@@ -0,0 +1,122 @@
+// Package diagnostic records how each saved filter was translated when its sections were last built.
+package diagnostic
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOk            = "ok"
+	StatusPartial       = "partial"
+	StatusRefused       = "refused"
+	StatusError         = "error"
+	StatusCountMismatch = "count mismatch"
+)
+
+// Diagnostic is the outcome of translating a saved filter and querying its scenes.
+type Diagnostic struct {
+	// Build is what the filter was built for, e.g. the sections of a profile or an ad-hoc library.
+	Build      string `json:"build"`
+	FilterId   string `json:"filterId"`
+	FilterName string `json:"filterName"`
+	Mode       string `json:"mode"`
+	Source     string `json:"source"`
+	// Unsupported lists the criteria left out of the translated filter.
+	Unsupported []string `json:"unsupported,omitempty"`
+	Error       string   `json:"error,omitempty"`
+	// Refused is set when STRICT_FILTERS skipped the partially translated filter.
+	Refused bool `json:"refused,omitempty"`
+	// Scenes is the number of scenes, or markers, of the sections built from the filter.
+	Scenes int `json:"scenes"`
+	// Count is the number of scenes Stash counts for the translated filter, regardless of limits.
+	Count *int `json:"count,omitempty"`
+	// StashCount is the number of scenes Stash counts for the criteria of the saved filter as they are, unset if Stash
+	// rejects them.
+	StashCount *int      `json:"stashCount,omitempty"`
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+func (d Diagnostic) Status() string {
+	switch {
+	case d.Refused:
+		return StatusRefused
+	case d.Error != "":
+		return StatusError
+	case len(d.Unsupported) > 0:
+		return StatusPartial
+	case d.Count != nil && d.StashCount != nil && *d.Count != *d.StashCount:
+		return StatusCountMismatch
+	}
+	return StatusOk
+}
+
+type key struct {
+	build    string
+	filterId string
+}
+
+var diagnostics struct {
+	mu    sync.Mutex
+	byKey map[key]Diagnostic
+}
+
+type buildKey struct{}
+
+// WithBuild returns ctx with the build that diagnostics recorded with it are of.
+func WithBuild(ctx context.Context, build string) context.Context {
+	return context.WithValue(ctx, buildKey{}, build)
+}
+
+// Record keeps d as the latest diagnostic of its filter in the build of ctx.
+func Record(ctx context.Context, d Diagnostic) {
+	d.Build, _ = ctx.Value(buildKey{}).(string)
+	d.CheckedAt = time.Now()
+	sort.Strings(d.Unsupported)
+	diagnostics.mu.Lock()
+	defer diagnostics.mu.Unlock()
+	if diagnostics.byKey == nil {
+		diagnostics.byKey = make(map[key]Diagnostic)
+	}
+	diagnostics.byKey[key{build: d.Build, filterId: d.FilterId}] = d
+}
+
+// Reset drops the diagnostics of build, call when it's built anew so filters no longer in it are left out.
+func Reset(build string) {
+	ResetWhere(func(b string) bool { return b == build })
+}
+
+// ResetWhere drops the diagnostics of the builds that match.
+func ResetWhere(match func(build string) bool) {
+	diagnostics.mu.Lock()
+	defer diagnostics.mu.Unlock()
+	for k := range diagnostics.byKey {
+		if match(k.build) {
+			delete(diagnostics.byKey, k)
+		}
+	}
+}
+
+// All returns the latest diagnostic of every filter and build, ordered by filter name and build.
+func All() []Diagnostic {
+	diagnostics.mu.Lock()
+	defer diagnostics.mu.Unlock()
+	ds := make([]Diagnostic, 0, len(diagnostics.byKey))
+	for _, d := range diagnostics.byKey {
+		ds = append(ds, d)
+	}
+	sort.Slice(ds, func(i, j int) bool {
+		a, b := strings.ToLower(ds[i].FilterName), strings.ToLower(ds[j].FilterName)
+		if a != b {
+			return a < b
+		}
+		if ds[i].FilterId != ds[j].FilterId {
+			return ds[i].FilterId < ds[j].FilterId
+		}
+		return ds[i].Build < ds[j].Build
+	})
+	return ds
+}
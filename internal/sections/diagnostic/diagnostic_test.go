@@ -0,0 +1,47 @@
+package diagnostic
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDiagnostic_Status(t *testing.T) {
+	count := func(n int) *int { return &n }
+	tests := []struct {
+		name string
+		d    Diagnostic
+		want string
+	}{
+		{"ok", Diagnostic{Scenes: 3}, StatusOk},
+		{"partial", Diagnostic{Scenes: 3, Unsupported: []string{"OR.x"}}, StatusPartial},
+		{"refused before partial", Diagnostic{Unsupported: []string{"x"}, Refused: true, Error: "refused"}, StatusRefused},
+		{"error", Diagnostic{Error: "boom"}, StatusError},
+		{"counts", Diagnostic{Scenes: 2, Count: count(3), StashCount: count(3)}, StatusOk},
+		{"count mismatch", Diagnostic{Scenes: 2, Count: count(3), StashCount: count(4)}, StatusCountMismatch},
+		{"stash count unknown", Diagnostic{Scenes: 2, Count: count(3)}, StatusOk},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.d.Status(); got != tt.want {
+				t.Errorf("Status() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	defer ResetWhere(func(string) bool { return true })
+	a, b := WithBuild(context.Background(), "a"), WithBuild(context.Background(), "b")
+	Record(a, Diagnostic{FilterId: "1", FilterName: "x", Scenes: 1})
+	Record(b, Diagnostic{FilterId: "1", FilterName: "x", Scenes: 2})
+	Record(a, Diagnostic{FilterId: "2", FilterName: "y"})
+	if ds := All(); len(ds) != 3 || ds[0].Build != "a" || ds[1].Build != "b" || ds[1].Scenes != 2 {
+		t.Errorf("All() = %+v, want the filter of both builds", ds)
+	}
+
+	Reset("a")
+	Record(a, Diagnostic{FilterId: "1", FilterName: "x", Scenes: 3})
+	if ds := All(); len(ds) != 2 || ds[0].Scenes != 3 || ds[1].Build != "b" {
+		t.Errorf("All() after Reset = %+v, want filter 2 of build a dropped", ds)
+	}
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// ErrFilterNotFound is returned for an ad-hoc library of a saved filter name that doesn't exist.
+var ErrFilterNotFound = errors.New("saved filter not found")
+
+// SectionsByAdHoc builds the sections of a one-off library, either of the saved filter named filterName or of expr.
+// expr is the name of a saved filter if one matches exactly, otherwise a query.
+func SectionsByAdHoc(ctx context.Context, client graphql.Client, expr string, filterName string) ([]section.Section, error) {
+	name := filterName
+	if name == "" {
+		name = expr
+	}
+	savedFilter, err := stash.FindSavedFilterByName(ctx, client, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if savedFilter == nil {
+		if filterName != "" {
+			return nil, fmt.Errorf("%w: %s", ErrFilterNotFound, filterName)
+		}
+		s, err := sectionFromQuery(ctx, client, "", expr, Overrides{})
+		if err != nil {
+			return nil, err
+		}
+		return []section.Section{s}, nil
+	}
+
+	ss, err := sectionsFromSavedFilterFuncBuilder(ctx, client, "", "Ad-hoc", Overrides{})(*savedFilter)
+	if errors.Is(err, errNoScenesFound) {
+		return nil, nil
+	}
+	return ss, err
+}
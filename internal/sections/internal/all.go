@@ -8,6 +8,7 @@ import (
 	"stash-vr/internal/sections/section"
 	"stash-vr/internal/stash/filter"
 	"stash-vr/internal/stash/gql"
+	"time"
 )
 
 func SectionWithAllScenes(ctx context.Context, client graphql.Client) (section.Section, error) {
@@ -19,6 +20,7 @@ func SectionWithAllScenes(ctx context.Context, client graphql.Client) (section.S
 		SceneFilter: gql.SceneFilterType{},
 	}
 
+	start := time.Now()
 	scenesResponse, err := gql.FindScenePreviewsByFilter(ctx, client, &fq.SceneFilter, &fq.FilterOpts)
 	if err != nil {
 		return section.Section{}, fmt.Errorf("FindScenePreviewsByFilter filter=%+v: %w", logger.AsJsonStr(fq), err)
@@ -27,6 +29,8 @@ func SectionWithAllScenes(ctx context.Context, client graphql.Client) (section.S
 	s := section.Section{
 		Name:             "All",
 		PreviewPartsList: make([]gql.ScenePreviewParts, len(scenesResponse.FindScenes.Scenes)),
+		BuiltAt:          start,
+		FilterDuration:   time.Since(start),
 	}
 
 	for i, v := range scenesResponse.FindScenes.Scenes {
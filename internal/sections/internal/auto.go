@@ -0,0 +1,208 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"stash-vr/internal/logger"
+	"stash-vr/internal/sections/definition"
+	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash/filter"
+	"stash-vr/internal/stash/gql"
+	"stash-vr/internal/util"
+	"strings"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/rs/zerolog/log"
+)
+
+// autoConcurrency caps the concurrent queries of generated sections, a library can have hundreds of tags.
+const autoConcurrency = 8
+
+// autoItem is a tag, studio, performer or marker tag to generate a section for.
+type autoItem struct {
+	id   string
+	name string
+	// sceneCount is the number of markers for marker tags.
+	sceneCount int
+	parents    []string
+}
+
+// sectionsByAuto generates a section per tag, studio, favourite performer or marker tag as selected by d.Auto.
+func sectionsByAuto(ctx context.Context, client graphql.Client, prefix string, d definition.Definition) ([]section.Section, error) {
+	ctx = sourceLogContext(ctx, d.Source)
+
+	items, err := findAutoItems(ctx, client, d.Source)
+	if err != nil {
+		return nil, err
+	}
+	items = selectAutoItems(items, d.Auto)
+	log.Ctx(ctx).Debug().Int("count", len(items)).Msg("Generating sections")
+
+	sections := util.Transform[autoItem, section.Section](func(item autoItem) (section.Section, error) {
+		s, err := sectionFromAutoItem(ctx, client, prefix, d, item)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("name", item.name).Msg("Section skipped")
+			return section.Section{}, err
+		}
+		if s.Len() == 0 {
+			return section.Section{}, errNoScenesFound
+		}
+		return s, nil
+	}).OrderedLimit(items, autoConcurrency)
+
+	return sections, nil
+}
+
+func findAutoItems(ctx context.Context, client graphql.Client, source string) ([]autoItem, error) {
+	var items []autoItem
+	switch source {
+	case definition.SourceTags:
+		response, err := gql.FindTags(ctx, client, nil, "name", gql.SortDirectionEnumAsc)
+		if err != nil {
+			return nil, fmt.Errorf("FindTags: %w", err)
+		}
+		for _, t := range response.FindTags.Tags {
+			item := autoItem{id: t.Id, name: t.Name, sceneCount: t.Scene_count}
+			for _, p := range t.Parents {
+				item.parents = append(item.parents, p.Name)
+			}
+			items = append(items, item)
+		}
+	case definition.SourceStudios:
+		response, err := gql.FindAllStudios(ctx, client)
+		if err != nil {
+			return nil, fmt.Errorf("FindAllStudios: %w", err)
+		}
+		for _, s := range response.FindStudios.Studios {
+			items = append(items, autoItem{id: s.Id, name: s.Name, sceneCount: s.Scene_count})
+		}
+	case definition.SourceMarkerTags:
+		response, err := gql.FindMarkerTags(ctx, client)
+		if err != nil {
+			return nil, fmt.Errorf("FindMarkerTags: %w", err)
+		}
+		for _, t := range response.FindTags.Tags {
+			item := autoItem{id: t.Id, name: t.Name, sceneCount: t.Scene_marker_count}
+			for _, p := range t.Parents {
+				item.parents = append(item.parents, p.Name)
+			}
+			items = append(items, item)
+		}
+	case definition.SourcePerformers:
+		response, err := gql.FindFavoritePerformers(ctx, client)
+		if err != nil {
+			return nil, fmt.Errorf("FindFavoritePerformers: %w", err)
+		}
+		for _, p := range response.FindPerformers.Performers {
+			items = append(items, autoItem{id: p.Id, name: p.Name, sceneCount: p.Scene_count})
+		}
+	}
+	return items, nil
+}
+
+// selectAutoItems filters, orders and caps items as configured.
+func selectAutoItems(items []autoItem, auto definition.Auto) []autoItem {
+	minScenes := auto.MinScenes
+	if minScenes < 1 {
+		minScenes = 1
+	}
+
+	selected := make([]autoItem, 0, len(items))
+	for _, item := range items {
+		if item.sceneCount < minScenes {
+			continue
+		}
+		if len(auto.Include) > 0 && !containsFold(auto.Include, item.name) {
+			continue
+		}
+		if containsFold(auto.Exclude, item.name) {
+			continue
+		}
+		if auto.Parent != "" && !containsFold(item.parents, auto.Parent) {
+			continue
+		}
+		selected = append(selected, item)
+	}
+
+	if auto.Order == definition.AutoOrderName {
+		sort.SliceStable(selected, func(i, j int) bool {
+			return strings.ToLower(selected[i].name) < strings.ToLower(selected[j].name)
+		})
+	} else {
+		sort.SliceStable(selected, func(i, j int) bool {
+			return selected[i].sceneCount > selected[j].sceneCount
+		})
+	}
+
+	if auto.Top > 0 && len(selected) > auto.Top {
+		selected = selected[:auto.Top]
+	}
+	return selected
+}
+
+func sectionFromAutoItem(ctx context.Context, client graphql.Client, prefix string, d definition.Definition, item autoItem) (section.Section, error) {
+	fq := filter.Filter{FilterOpts: gql.FindFilterType{
+		Per_page:  -1,
+		Sort:      d.Sort,
+		Direction: filter.DirectionOrDefault(d.Sort, d.Direction),
+	}}
+	if d.Limit > 0 {
+		fq.FilterOpts.Per_page = d.Limit
+	}
+
+	ids := []string{item.id}
+	if d.Source == definition.SourceMarkerTags {
+		return sectionFromMarkerTag(ctx, client, prefix, d, item, fq.FilterOpts)
+	}
+	switch d.Source {
+	case definition.SourceTags:
+		fq.SceneFilter.Tags = &gql.HierarchicalMultiCriterionInput{Value: ids, Modifier: gql.CriterionModifierIncludes}
+	case definition.SourceStudios:
+		fq.SceneFilter.Studios = &gql.HierarchicalMultiCriterionInput{Value: ids, Modifier: gql.CriterionModifierIncludes}
+	case definition.SourcePerformers:
+		fq.SceneFilter.Performers = &gql.MultiCriterionInput{Value: ids, Modifier: gql.CriterionModifierIncludes}
+	}
+
+	start := time.Now()
+	scenesResponse, err := gql.FindScenePreviewsByFilter(ctx, client, &fq.SceneFilter, &fq.FilterOpts)
+	if err != nil {
+		return section.Section{}, fmt.Errorf("FindScenePreviewsByFilter filter=%+v: %w", logger.AsJsonStr(fq), err)
+	}
+
+	s := section.Section{
+		Name:             prefix + item.name,
+		FilterId:         fmt.Sprintf("%s:%s", d.Source, item.id),
+		PreviewPartsList: make([]gql.ScenePreviewParts, len(scenesResponse.FindScenes.Scenes)),
+		BuiltAt:          start,
+		FilterDuration:   time.Since(start),
+	}
+	for i, v := range scenesResponse.FindScenes.Scenes {
+		s.PreviewPartsList[i] = v.ScenePreviewParts
+	}
+	return s, nil
+}
+
+// sectionFromMarkerTag builds a section of the markers with item as primary tag.
+func sectionFromMarkerTag(ctx context.Context, client graphql.Client, prefix string, d definition.Definition, item autoItem, filterOpts gql.FindFilterType) (section.Section, error) {
+	f := filter.SceneMarkerFilter{FilterOpts: filterOpts}
+	f.SceneMarkerFilter.Tag_id = item.id
+
+	s, err := sectionFromMarkers(ctx, client, f)
+	if err != nil {
+		return section.Section{}, err
+	}
+	s.Name = prefix + item.name
+	s.FilterId = fmt.Sprintf("%s:%s", d.Source, item.id)
+	return s, nil
+}
+
+func containsFold(ss []string, s string) bool {
+	for _, v := range ss {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
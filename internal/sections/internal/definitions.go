@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"stash-vr/internal/sections/definition"
+	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash"
+	"stash-vr/internal/stash/gql"
+	"stash-vr/internal/util"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/rs/zerolog/log"
+)
+
+// SectionsByDefinitions builds the sections of the sections file, in order.
+func SectionsByDefinitions(ctx context.Context, client graphql.Client, definitions []definition.Definition) ([]section.Section, error) {
+	sectionLists := util.Transform[definition.Definition, []section.Section](func(d definition.Definition) ([]section.Section, error) {
+		ss, err := sectionsByDefinition(ctx, client, d)
+		if err != nil {
+			return nil, err
+		}
+		for i := range ss {
+			ss[i].Player = d.Player
+		}
+		return ss, nil
+	}).Ordered(definitions)
+
+	return flatten(sectionLists), nil
+}
+
+func sectionsByDefinition(ctx context.Context, client graphql.Client, d definition.Definition) ([]section.Section, error) {
+	if d.Split {
+		pageSize := d.Limit
+		d.Limit, d.Split = 0, false
+		ss, err := sectionsByDefinition(ctx, client, d)
+		for i := range ss {
+			ss[i].PageSize = pageSize
+		}
+		return ss, err
+	}
+
+	prefix := ""
+	if d.Group != "" {
+		prefix = d.Group + ": "
+	}
+
+	if d.Source != "" {
+		return sectionsBySource(ctx, client, prefix, d)
+	}
+
+	overrides := Overrides{
+		Name:      d.Name,
+		Limit:     d.Limit,
+		Sort:      d.Sort,
+		Direction: gql.SortDirectionEnum(d.Direction),
+	}
+
+	if d.Query != "" {
+		s, err := sectionFromQuery(ctx, client, prefix, d.Query, overrides)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("Section skipped")
+			return nil, err
+		}
+		if s.Len() == 0 {
+			return nil, nil
+		}
+		return []section.Section{s}, nil
+	}
+
+	var savedFilters []gql.SavedFilterParts
+	if d.FilterId != "" {
+		savedFilters = stash.FindFiltersById(ctx, client, []string{d.FilterId})
+	} else {
+		savedFilters = stash.FindFiltersByName(ctx, client, []string{d.FilterName})
+	}
+	if len(savedFilters) == 0 {
+		return nil, fmt.Errorf("saved filter not found")
+	}
+
+	return sectionsFromSavedFilterFuncBuilder(ctx, client, prefix, "Sections File", overrides)(savedFilters[0])
+}
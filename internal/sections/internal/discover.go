@@ -0,0 +1,208 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"stash-vr/internal/config"
+	"stash-vr/internal/logger"
+	"stash-vr/internal/sections/definition"
+	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash/filter"
+	"stash-vr/internal/stash/gql"
+	"sync"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	discoverName         = "Discover"
+	discoverDefaultLimit = 50
+	// discoverCandidates is how many times the limit is sampled from Stash when weighting, to pick from.
+	discoverCandidates = 4
+	// discoverSeedMax keeps seeds in the range of those generated by Stash's UI.
+	discoverSeedMax   = 100_000_000
+	discoverFileName  = "discover.json"
+	discoverDayLayout = "2006-01-02"
+)
+
+type discoverScene = gql.FindDiscoverScenesFindScenesFindScenesResultTypeScenesScene
+
+// DiscoverSeed is the seed of the random sort of the discover section. It rotates daily or when rotated on demand.
+type DiscoverSeed struct {
+	Seed int64  `json:"seed"`
+	Day  string `json:"day"`
+}
+
+var discoverSeed struct {
+	mu     sync.Mutex
+	loaded bool
+	seed   DiscoverSeed
+}
+
+func discoverPath() string {
+	return filepath.Join(config.Get().DataDir, discoverFileName)
+}
+
+// CurrentDiscoverSeed returns the seed of today, the one rotated to today if any, otherwise derived from the date.
+func CurrentDiscoverSeed() DiscoverSeed {
+	discoverSeed.mu.Lock()
+	defer discoverSeed.mu.Unlock()
+	loadDiscoverSeed()
+
+	today := time.Now().Format(discoverDayLayout)
+	if discoverSeed.seed.Day != today {
+		discoverSeed.seed = DiscoverSeed{Seed: hashSeed(today), Day: today}
+		saveDiscoverSeed()
+	}
+	return discoverSeed.seed
+}
+
+// RotateDiscoverSeed replaces the seed of today with a new one.
+func RotateDiscoverSeed() DiscoverSeed {
+	discoverSeed.mu.Lock()
+	defer discoverSeed.mu.Unlock()
+	loadDiscoverSeed()
+
+	now := time.Now()
+	seed := hashSeed(now.Format(time.RFC3339Nano))
+	if seed == discoverSeed.seed.Seed {
+		seed = (seed + 1) % discoverSeedMax
+	}
+	discoverSeed.seed = DiscoverSeed{Seed: seed, Day: now.Format(discoverDayLayout)}
+	saveDiscoverSeed()
+	return discoverSeed.seed
+}
+
+func hashSeed(s string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	return int64(h.Sum64() % discoverSeedMax)
+}
+
+// loadDiscoverSeed must be called with discoverSeed.mu held.
+func loadDiscoverSeed() {
+	if discoverSeed.loaded {
+		return
+	}
+	discoverSeed.loaded = true
+
+	b, err := os.ReadFile(discoverPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("path", discoverPath()).Msg("Failed to read discover seed")
+		return
+	}
+	if err := json.Unmarshal(b, &discoverSeed.seed); err != nil {
+		log.Warn().Err(err).Str("path", discoverPath()).Msg("Failed to parse discover seed")
+	}
+}
+
+// saveDiscoverSeed must be called with discoverSeed.mu held.
+// The seed is persisted so that the section stays the same across restarts.
+func saveDiscoverSeed() {
+	b, _ := json.Marshal(discoverSeed.seed)
+	err := os.MkdirAll(config.Get().DataDir, 0700)
+	if err == nil {
+		err = os.WriteFile(discoverPath(), b, 0600)
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("path", discoverPath()).Msg("Failed to save discover seed")
+	}
+}
+
+// sectionDiscover samples scenes with Stash's seeded random sort. Being seeded the section only changes
+// when the seed rotates or the library changes.
+func sectionDiscover(ctx context.Context, client graphql.Client, prefix string, d definition.Definition) ([]section.Section, error) {
+	ctx = sourceLogContext(ctx, d.Source)
+
+	limit := d.Limit
+	if limit == 0 {
+		limit = discoverDefaultLimit
+	}
+	seed := CurrentDiscoverSeed()
+
+	fq := filter.SortedFilter(fmt.Sprintf("random_%d", seed.Seed), gql.SortDirectionEnumAsc, limit)
+	if d.Discover.Weight != "" {
+		fq.FilterOpts.Per_page = limit * discoverCandidates
+	}
+
+	start := time.Now()
+	scenesResponse, err := gql.FindDiscoverScenes(ctx, client, &fq.SceneFilter, &fq.FilterOpts)
+	if err != nil {
+		return nil, fmt.Errorf("FindDiscoverScenes filter=%+v: %w", logger.AsJsonStr(fq), err)
+	}
+	scenes := scenesResponse.FindScenes.Scenes
+	if len(scenes) == 0 {
+		log.Ctx(ctx).Debug().Msg("Section skipped: 0 scenes")
+		return nil, nil
+	}
+	if d.Discover.Weight != "" {
+		scenes = weightedSample(scenes, seed.Seed, d.Discover.Weight, limit)
+	}
+
+	name := d.Name
+	if name == "" {
+		name = discoverName
+	}
+	s := section.Section{
+		Name:             prefix + name,
+		FilterId:         definition.SourceDiscover,
+		PreviewPartsList: make([]gql.ScenePreviewParts, len(scenes)),
+		BuiltAt:          start,
+		FilterDuration:   time.Since(start),
+	}
+	for i, v := range scenes {
+		s.PreviewPartsList[i] = v.ScenePreviewParts
+	}
+	log.Ctx(ctx).Debug().Int64("seed", seed.Seed).Str("weight", d.Discover.Weight).Int("scenes", len(scenes)).Msg("Discover section built")
+	return []section.Section{s}, nil
+}
+
+// weightedSample picks limit scenes by weighted random sampling (Efraimidis-Spirakis). The random
+// numbers are derived from seed and scene id so that the pick is stable for the same seed.
+func weightedSample(scenes []*discoverScene, seed int64, weight string, limit int) []*discoverScene {
+	keys := make(map[string]float64, len(scenes))
+	for _, s := range scenes {
+		keys[s.Id] = math.Pow(uniform(seed, s.Id), 1/discoverWeight(s, weight))
+	}
+	sampled := make([]*discoverScene, len(scenes))
+	copy(sampled, scenes)
+	sort.SliceStable(sampled, func(i, j int) bool {
+		return keys[sampled[i].Id] > keys[sampled[j].Id]
+	})
+	if len(sampled) > limit {
+		sampled = sampled[:limit]
+	}
+	return sampled
+}
+
+func discoverWeight(s *discoverScene, weight string) float64 {
+	switch weight {
+	case definition.DiscoverWeightUnwatched:
+		if s.Play_count == 0 {
+			return 3
+		}
+	case definition.DiscoverWeightRated:
+		// 1 for unrated up to 5 for 100.
+		return 1 + float64(s.Rating100)/25
+	}
+	return 1
+}
+
+// uniform returns a number in (0,1) determined by seed and id.
+func uniform(seed int64, id string) float64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(fmt.Sprintf("%d:%s", seed, id)))
+	return (float64(h.Sum64()>>11) + 0.5) / (1 << 53)
+}
@@ -11,7 +11,7 @@ import (
 func SectionsByFilterName(ctx context.Context, client graphql.Client, prefix string, filterNames []string) ([]section.Section, error) {
 	savedFilters := stash.FindFiltersByName(ctx, client, filterNames)
 
-	sections := sectionFromSavedFilterFuncBuilder(ctx, client, prefix, "Filter List").Ordered(savedFilters)
+	sections := flatten(sectionsFromSavedFilterFuncBuilder(ctx, client, prefix, "Filter List", Overrides{}).Ordered(savedFilters))
 
 	return sections, nil
 }
@@ -19,7 +19,7 @@ func SectionsByFilterName(ctx context.Context, client graphql.Client, prefix str
 func SectionsByFilterIds(ctx context.Context, client graphql.Client, prefix string, filterIds []string) ([]section.Section, error) {
 	savedFilters := stash.FindFiltersById(ctx, client, filterIds)
 
-	sections := sectionFromSavedFilterFuncBuilder(ctx, client, prefix, "Filter List").Ordered(savedFilters)
+	sections := flatten(sectionsFromSavedFilterFuncBuilder(ctx, client, prefix, "Filter List", Overrides{}).Ordered(savedFilters))
 
 	return sections, nil
 }
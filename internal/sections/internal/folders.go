@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"stash-vr/internal/config"
+	"stash-vr/internal/sections/definition"
+	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash"
+	"stash-vr/internal/stash/gql"
+	"strings"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/rs/zerolog/log"
+)
+
+// rgxWindowsDrive matches paths starting with a drive letter, e.g. D:\Media.
+var rgxWindowsDrive = regexp.MustCompile(`^[A-Za-z]:`)
+
+// sectionsByFolders builds a section per folder below the folder roots, ordered by name.
+// Folders deeper than the depth are part of their ancestor at that depth.
+func sectionsByFolders(ctx context.Context, client graphql.Client, prefix string, d definition.Definition) ([]section.Section, error) {
+	ctx = sourceLogContext(ctx, d.Source)
+
+	roots := d.Folders.Roots
+	if len(roots) == 0 {
+		roots = splitFolderRoots(config.Get().FolderRoots)
+	}
+	if len(roots) == 0 {
+		log.Ctx(ctx).Debug().Msg("Section skipped: no folder roots configured")
+		return nil, nil
+	}
+	depth := config.Get().FolderDepth
+	if d.Folders.Depth != nil {
+		depth = *d.Folders.Depth
+	}
+
+	start := time.Now()
+	library, err := stash.FindAllSceneScans(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("FindAllSceneScans: %w", err)
+	}
+
+	type file struct {
+		path  string
+		scene gql.ScenePreviewParts
+	}
+	folders := make(map[string][]file)
+	for _, s := range library {
+		if len(s.SceneScanParts.Files) == 0 {
+			continue
+		}
+		p := s.SceneScanParts.Files[0].Path
+		if name, ok := folderName(roots, depth, p); ok {
+			folders[name] = append(folders[name], file{path: toSlash(p), scene: s.ScenePreviewParts})
+		}
+	}
+
+	names := make([]string, 0, len(folders))
+	for name := range folders {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+	log.Ctx(ctx).Debug().Int("count", len(names)).Int("depth", depth).Msg("Generating sections")
+
+	sections := make([]section.Section, len(names))
+	for i, name := range names {
+		files := folders[name]
+		sort.Slice(files, func(i, j int) bool {
+			return strings.ToLower(files[i].path) < strings.ToLower(files[j].path)
+		})
+		s := section.Section{
+			Name:             prefix + name,
+			FilterId:         fmt.Sprintf("%s:%s", definition.SourceFolders, name),
+			PreviewPartsList: make([]gql.ScenePreviewParts, len(files)),
+			BuiltAt:          start,
+			FilterDuration:   time.Since(start),
+		}
+		for j, f := range files {
+			s.PreviewPartsList[j] = f.scene
+		}
+		sections[i] = s
+	}
+	return sections, nil
+}
+
+// splitFolderRoots splits the comma separated FOLDER_ROOTS.
+func splitFolderRoots(s string) []string {
+	var roots []string
+	for _, root := range strings.Split(s, ",") {
+		if root = strings.TrimSpace(root); root != "" {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// folderName returns the name of the folder section of the file at p: its folder relative to the first root
+// containing it, cut to depth levels (0 is unlimited). Files directly in a root belong to a section named after the
+// root, with several roots every name starts with the root's name. Windows and POSIX separators are both accepted.
+func folderName(roots []string, depth int, p string) (string, bool) {
+	p = toSlash(p)
+	for _, root := range roots {
+		r := strings.TrimRight(toSlash(root), "/")
+		rel, ok := cutFolderPrefix(p, r, isWindowsPath(root))
+		if !ok {
+			continue
+		}
+
+		dirs := strings.Split(rel, "/")
+		dirs = dirs[:len(dirs)-1]
+		if depth > 0 && len(dirs) > depth {
+			dirs = dirs[:depth]
+		}
+		name := strings.Join(dirs, "/")
+		if name == "" || len(roots) > 1 {
+			name = path.Join(rootName(r), name)
+		}
+		return name, true
+	}
+	return "", false
+}
+
+// cutFolderPrefix returns p relative to the folder root, ignoring case for Windows paths.
+func cutFolderPrefix(p string, root string, ignoreCase bool) (string, bool) {
+	prefix := root + "/"
+	if len(p) <= len(prefix) {
+		return "", false
+	}
+	if ignoreCase && !strings.EqualFold(p[:len(prefix)], prefix) || !ignoreCase && p[:len(prefix)] != prefix {
+		return "", false
+	}
+	return p[len(prefix):], true
+}
+
+func rootName(root string) string {
+	if root == "" {
+		return "/"
+	}
+	return path.Base(root)
+}
+
+func isWindowsPath(p string) bool {
+	return strings.Contains(p, "\\") || rgxWindowsDrive.MatchString(p)
+}
+
+func toSlash(p string) string {
+	return strings.ReplaceAll(p, "\\", "/")
+}
@@ -0,0 +1,31 @@
+package internal
+
+import "testing"
+
+func TestFolderName(t *testing.T) {
+	tests := []struct {
+		name   string
+		roots  []string
+		depth  int
+		path   string
+		want   string
+		wantOk bool
+	}{
+		{"posix", []string{"/media/"}, 1, "/media/VR/beach/day.mp4", "VR", true},
+		{"depth", []string{"/media"}, 2, "/media/VR/beach/day.mp4", "VR/beach", true},
+		{"unlimited", []string{"/media"}, 0, "/media/VR/beach/sunny/day.mp4", "VR/beach/sunny", true},
+		{"in root", []string{"/media"}, 1, "/media/day.mp4", "media", true},
+		{"outside root", []string{"/media"}, 1, "/mediaX/VR/day.mp4", "", false},
+		{"windows", []string{`D:\Media`}, 2, `d:\media\VR\Beach\day.mp4`, "VR/Beach", true},
+		{"windows root with slashes", []string{"D:/Media"}, 1, `D:\Media\Flat\day.mp4`, "Flat", true},
+		{"several roots", []string{"/media", "/archive"}, 1, "/archive/VR/day.mp4", "archive/VR", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := folderName(tt.roots, tt.depth, tt.path)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("folderName() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -2,21 +2,94 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/Khan/genqlient/graphql"
+	"github.com/rs/zerolog/log"
+	"stash-vr/internal/logger"
 	"stash-vr/internal/sections/section"
 	"stash-vr/internal/stash"
+	"stash-vr/internal/stash/filter"
+	"stash-vr/internal/stash/gql"
+	"stash-vr/internal/util"
+	"time"
 )
 
+var errUnsupportedMode = errors.New("unsupported mode")
+
 func SectionsByFrontpage(ctx context.Context, client graphql.Client, prefix string) ([]section.Section, error) {
-	filterIds, err := stash.FindSavedFilterIdsByFrontPage(ctx, client)
+	items, err := stash.FindFrontPageItems(ctx, client)
 	if err != nil {
-		return nil, fmt.Errorf("FindSavedFilterIdsByFrontPage: %w", err)
+		return nil, fmt.Errorf("FindFrontPageItems: %w", err)
 	}
 
-	savedFilters := stash.FindFiltersById(ctx, client, filterIds)
+	savedFilterFunc := sectionsFromSavedFilterFuncBuilder(ctx, client, prefix, "Front Page", Overrides{})
+
+	sectionLists := util.Transform[stash.FrontPageItem, []section.Section](func(item stash.FrontPageItem) ([]section.Section, error) {
+		if item.Custom != nil {
+			s, err := sectionFromCustomFilter(ctx, client, prefix, *item.Custom)
+			if err != nil {
+				return nil, err
+			}
+			return []section.Section{s}, nil
+		}
+		savedFilters := stash.FindFiltersById(ctx, client, []string{item.SavedFilterId})
+		if len(savedFilters) == 0 {
+			return nil, fmt.Errorf("saved filter %s not found", item.SavedFilterId)
+		}
+		return savedFilterFunc(savedFilters[0])
+	}).Ordered(items)
+
+	return flatten(sectionLists), nil
+}
+
+func sectionFromCustomFilter(ctx context.Context, client graphql.Client, prefix string, customFilter stash.FrontPageCustomFilter) (section.Section, error) {
+	ctx = log.Ctx(sourceLogContext(ctx, "Front Page")).With().Str("filterName", customFilter.Name).Str("filterMode", string(customFilter.Mode)).Logger().WithContext(ctx)
+
+	fq := filter.SortedFilter(customFilter.SortBy, customFilter.Direction, customFilter.Limit)
+	filterId := fmt.Sprintf("frontpage:%s:%s", customFilter.SortBy, customFilter.Direction)
+
+	if customFilter.Mode == gql.FilterModeSceneMarkers {
+		s, err := sectionFromMarkers(ctx, client, filter.SceneMarkerFilter{FilterOpts: fq.FilterOpts})
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("Filter skipped")
+			return section.Section{}, err
+		}
+		if s.Len() == 0 {
+			log.Ctx(ctx).Debug().Msg("Filter skipped: 0 markers")
+			return section.Section{}, errNoScenesFound
+		}
+		s.Name = prefix + customFilter.Name
+		s.FilterId = "markers:" + filterId
+		log.Ctx(sectionLogContext(ctx, s)).Debug().Msg("Section built")
+		return s, nil
+	}
+	if customFilter.Mode != gql.FilterModeScenes {
+		log.Ctx(ctx).Debug().Msg("Filter skipped: Premade filter on front page is not of scenes or markers")
+		return section.Section{}, errUnsupportedMode
+	}
 
-	sections := sectionFromSavedFilterFuncBuilder(ctx, client, prefix, "Front Page").Ordered(savedFilters)
+	start := time.Now()
+	scenesResponse, err := gql.FindScenePreviewsByFilter(ctx, client, &fq.SceneFilter, &fq.FilterOpts)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Filter skipped")
+		return section.Section{}, fmt.Errorf("FindScenePreviewsByFilter filter=%+v: %w", logger.AsJsonStr(fq), err)
+	}
+	if len(scenesResponse.FindScenes.Scenes) == 0 {
+		log.Ctx(ctx).Debug().Msg("Filter skipped: 0 scenes")
+		return section.Section{}, errNoScenesFound
+	}
 
-	return sections, nil
+	s := section.Section{
+		Name:             prefix + customFilter.Name,
+		FilterId:         filterId,
+		PreviewPartsList: make([]gql.ScenePreviewParts, len(scenesResponse.FindScenes.Scenes)),
+		BuiltAt:          start,
+		FilterDuration:   time.Since(start),
+	}
+	for i, v := range scenesResponse.FindScenes.Scenes {
+		s.PreviewPartsList[i] = v.ScenePreviewParts
+	}
+	log.Ctx(sectionLogContext(ctx, s)).Debug().Msg("Section built")
+	return s, nil
 }
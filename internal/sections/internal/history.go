@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"stash-vr/internal/logger"
+	"stash-vr/internal/sections/definition"
+	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash/filter"
+	"stash-vr/internal/stash/gql"
+	"strings"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/rs/zerolog/log"
+)
+
+// PlayHistoryFilterIdPrefix prefixes the filter id of sections built from play history.
+const PlayHistoryFilterIdPrefix = "history:"
+
+var playHistoryNames = map[string]string{
+	definition.SourceContinueWatching: "Continue Watching",
+	definition.SourceRecentlyPlayed:   "Recently Played",
+	definition.SourceMostPlayed:       "Most Played",
+}
+
+// playHistoryFilter returns the filter of a play history source.
+func playHistoryFilter(source string) filter.Filter {
+	greaterThanZero := &gql.IntCriterionInput{Value: 0, Modifier: gql.CriterionModifierGreaterThan}
+	switch source {
+	case definition.SourceContinueWatching:
+		// Partially watched, Stash resets resume time when a scene is watched to the end.
+		return filter.Filter{
+			FilterOpts:  gql.FindFilterType{Per_page: -1, Sort: "last_played_at", Direction: gql.SortDirectionEnumDesc},
+			SceneFilter: gql.SceneFilterType{Resume_time: greaterThanZero},
+		}
+	case definition.SourceRecentlyPlayed:
+		return filter.Filter{
+			FilterOpts:  gql.FindFilterType{Per_page: -1, Sort: "last_played_at", Direction: gql.SortDirectionEnumDesc},
+			SceneFilter: gql.SceneFilterType{Play_count: greaterThanZero},
+		}
+	default:
+		return filter.Filter{
+			FilterOpts:  gql.FindFilterType{Per_page: -1, Sort: "play_count", Direction: gql.SortDirectionEnumDesc},
+			SceneFilter: gql.SceneFilterType{Play_count: greaterThanZero},
+		}
+	}
+}
+
+// sectionsByPlayHistory builds a section of Stash's global play history, the plays kept locally for user profiles
+// without sync to Stash aren't part of it.
+func sectionsByPlayHistory(ctx context.Context, client graphql.Client, prefix string, d definition.Definition) ([]section.Section, error) {
+	ctx = sourceLogContext(ctx, d.Source)
+
+	start := time.Now()
+	scenes, err := findPlayHistory(ctx, client, d.Source, d.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(scenes) == 0 {
+		log.Ctx(ctx).Debug().Msg("Section skipped: 0 scenes")
+		return nil, nil
+	}
+
+	name := d.Name
+	if name == "" {
+		name = playHistoryNames[d.Source]
+	}
+	s := section.Section{
+		Name:             prefix + name,
+		FilterId:         PlayHistoryFilterIdPrefix + d.Source,
+		PreviewPartsList: scenes,
+		BuiltAt:          start,
+		FilterDuration:   time.Since(start),
+	}
+	return []section.Section{s}, nil
+}
+
+// PlayHistoryScenes queries the first count scenes of the play history section with filterId.
+func PlayHistoryScenes(ctx context.Context, client graphql.Client, filterId string, count int) ([]gql.ScenePreviewParts, error) {
+	return findPlayHistory(ctx, client, strings.TrimPrefix(filterId, PlayHistoryFilterIdPrefix), count)
+}
+
+// findPlayHistory queries the scenes of a play history source, all if limit is 0.
+func findPlayHistory(ctx context.Context, client graphql.Client, source string, limit int) ([]gql.ScenePreviewParts, error) {
+	fq := playHistoryFilter(source)
+	if limit > 0 {
+		fq.FilterOpts.Per_page = limit
+	}
+	scenesResponse, err := gql.FindScenePreviewsByFilter(ctx, client, &fq.SceneFilter, &fq.FilterOpts)
+	if err != nil {
+		return nil, fmt.Errorf("FindScenePreviewsByFilter filter=%+v: %w", logger.AsJsonStr(fq), err)
+	}
+	scenes := make([]gql.ScenePreviewParts, len(scenesResponse.FindScenes.Scenes))
+	for i, v := range scenesResponse.FindScenes.Scenes {
+		scenes[i] = v.ScenePreviewParts
+	}
+	return scenes, nil
+}
+
+// IsPlayHistory reports whether s is built from play history.
+func IsPlayHistory(s section.Section) bool {
+	return strings.HasPrefix(s.FilterId, PlayHistoryFilterIdPrefix)
+}
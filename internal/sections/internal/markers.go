@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"stash-vr/internal/logger"
+	"stash-vr/internal/sections/diagnostic"
+	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash/filter"
+	"stash-vr/internal/stash/gql"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// sectionFromMarkerFilter builds a section of the markers matched by a saved filter of mode SCENE_MARKERS.
+func sectionFromMarkerFilter(ctx context.Context, client graphql.Client, prefix string, savedFilter gql.SavedFilterParts, overrides Overrides, d *diagnostic.Diagnostic) (section.Section, error) {
+	f, err := filter.SavedFilterToSceneMarkerFilter(ctx, savedFilter)
+	if err != nil {
+		return section.Section{}, fmt.Errorf("SavedFilterToSceneMarkerFilter: %w", err)
+	}
+	if err := checkUnsupported(d, f.Unsupported); err != nil {
+		return section.Section{}, err
+	}
+	if overrides.Limit > 0 {
+		f.FilterOpts.Per_page = overrides.Limit
+	}
+	if overrides.Sort != "" {
+		f.FilterOpts.Sort = overrides.Sort
+	}
+	if overrides.Direction != "" {
+		f.FilterOpts.Direction = overrides.Direction
+	}
+	if overrides.Name != "" {
+		savedFilter.Name = overrides.Name
+	}
+
+	s, err := sectionFromMarkers(ctx, client, f)
+	if err != nil {
+		return section.Section{}, err
+	}
+	s.Name = getSectionName(prefix, savedFilter)
+	s.FilterId = savedFilter.Id
+	return s, nil
+}
+
+// sectionFromMarkers queries the markers of f into an unnamed section.
+func sectionFromMarkers(ctx context.Context, client graphql.Client, f filter.SceneMarkerFilter) (section.Section, error) {
+	start := time.Now()
+	response, err := gql.FindSceneMarkersByFilter(ctx, client, &f.SceneMarkerFilter, &f.FilterOpts)
+	if err != nil {
+		return section.Section{}, fmt.Errorf("FindSceneMarkersByFilter filter=%+v: %w", logger.AsJsonStr(f), err)
+	}
+
+	s := section.Section{
+		Markers:        make([]section.Marker, 0, len(response.FindSceneMarkers.Scene_markers)),
+		BuiltAt:        start,
+		FilterDuration: time.Since(start),
+	}
+	for _, m := range response.FindSceneMarkers.Scene_markers {
+		if m.Scene == nil || len(m.Scene.Files) == 0 {
+			continue
+		}
+		var tag string
+		if m.Primary_tag != nil {
+			tag = m.Primary_tag.Name
+		}
+		s.Markers = append(s.Markers, section.Marker{
+			Id:         m.Id,
+			Title:      section.MarkerTitle(tag, m.Title),
+			Seconds:    m.Seconds,
+			Screenshot: m.Screenshot,
+			Scene:      m.Scene.ScenePreviewParts,
+		})
+	}
+	return s, nil
+}
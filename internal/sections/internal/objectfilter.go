@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"stash-vr/internal/logger"
+	"stash-vr/internal/sections/diagnostic"
+	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash/compat"
+	"stash-vr/internal/stash/filter"
+	"stash-vr/internal/stash/gql"
+	"stash-vr/internal/util"
+	"strings"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/rs/zerolog/log"
+)
+
+// sceneObject is a performer, studio or movie matched by a saved filter, it becomes a section of its scenes.
+type sceneObject struct {
+	id   string
+	name string
+}
+
+// sectionsFromObjectFilter builds a section per performer, studio or movie matched by a saved filter of that mode,
+// in the order of the saved filter. Overrides apply to the scenes of each section, a name override becomes a prefix.
+func sectionsFromObjectFilter(ctx context.Context, client graphql.Client, prefix string, savedFilter gql.SavedFilterParts, overrides Overrides, d *diagnostic.Diagnostic) ([]section.Section, error) {
+	objects, err := findSceneObjects(ctx, client, savedFilter, d)
+	if err != nil {
+		return nil, err
+	}
+	log.Ctx(ctx).Debug().Int("count", len(objects)).Msg("Generating sections")
+
+	if overrides.Name != "" {
+		prefix += overrides.Name + ": "
+	}
+	key := strings.ToLower(string(savedFilter.Mode))
+
+	sections := util.Transform[sceneObject, section.Section](func(o sceneObject) (section.Section, error) {
+		fq := filter.Filter{FilterOpts: gql.FindFilterType{
+			Per_page:  -1,
+			Sort:      overrides.Sort,
+			Direction: filter.DirectionOrDefault(overrides.Sort, string(overrides.Direction)),
+		}}
+		if overrides.Limit > 0 {
+			fq.FilterOpts.Per_page = overrides.Limit
+		}
+		ids := []string{o.id}
+		switch savedFilter.Mode {
+		case gql.FilterModePerformers:
+			fq.SceneFilter.Performers = &gql.MultiCriterionInput{Value: ids, Modifier: gql.CriterionModifierIncludes}
+		case gql.FilterModeStudios:
+			fq.SceneFilter.Studios = &gql.HierarchicalMultiCriterionInput{Value: ids, Modifier: gql.CriterionModifierIncludes}
+		case gql.FilterModeMovies, compat.FilterModeGroups:
+			fq.SceneFilter.Movies = &gql.MultiCriterionInput{Value: ids, Modifier: gql.CriterionModifierIncludes}
+			if fq.FilterOpts.Sort == "" {
+				fq.FilterOpts.Sort = compat.Current().MovieSceneSort()
+			}
+		}
+
+		start := time.Now()
+		scenesResponse, err := gql.FindScenePreviewsByFilter(ctx, client, &fq.SceneFilter, &fq.FilterOpts)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("name", o.name).Msg("Section skipped")
+			return section.Section{}, fmt.Errorf("FindScenePreviewsByFilter filter=%+v: %w", logger.AsJsonStr(fq), err)
+		}
+		if len(scenesResponse.FindScenes.Scenes) == 0 {
+			return section.Section{}, errNoScenesFound
+		}
+
+		s := section.Section{
+			Name:             prefix + o.name,
+			FilterId:         fmt.Sprintf("%s:%s", key, o.id),
+			PreviewPartsList: make([]gql.ScenePreviewParts, len(scenesResponse.FindScenes.Scenes)),
+			BuiltAt:          start,
+			FilterDuration:   time.Since(start),
+		}
+		for i, v := range scenesResponse.FindScenes.Scenes {
+			s.PreviewPartsList[i] = v.ScenePreviewParts
+		}
+		return s, nil
+	}).Ordered(objects)
+
+	return sections, nil
+}
+
+func findSceneObjects(ctx context.Context, client graphql.Client, savedFilter gql.SavedFilterParts, d *diagnostic.Diagnostic) ([]sceneObject, error) {
+	var objects []sceneObject
+	switch savedFilter.Mode {
+	case gql.FilterModePerformers:
+		f, err := filter.SavedFilterToPerformerFilter(ctx, savedFilter)
+		if err != nil {
+			return nil, fmt.Errorf("SavedFilterToPerformerFilter: %w", err)
+		}
+		if err := checkUnsupported(d, f.Unsupported); err != nil {
+			return nil, err
+		}
+		response, err := gql.FindPerformersByFilter(ctx, client, &f.PerformerFilter, &f.FilterOpts)
+		if err != nil {
+			return nil, fmt.Errorf("FindPerformersByFilter: %w", err)
+		}
+		for _, p := range response.FindPerformers.Performers {
+			objects = append(objects, sceneObject{id: p.Id, name: p.Name})
+		}
+	case gql.FilterModeStudios:
+		f, err := filter.SavedFilterToStudioFilter(ctx, savedFilter)
+		if err != nil {
+			return nil, fmt.Errorf("SavedFilterToStudioFilter: %w", err)
+		}
+		if err := checkUnsupported(d, f.Unsupported); err != nil {
+			return nil, err
+		}
+		response, err := gql.FindStudiosByFilter(ctx, client, &f.StudioFilter, &f.FilterOpts)
+		if err != nil {
+			return nil, fmt.Errorf("FindStudiosByFilter: %w", err)
+		}
+		for _, s := range response.FindStudios.Studios {
+			objects = append(objects, sceneObject{id: s.Id, name: s.Name})
+		}
+	case gql.FilterModeMovies, compat.FilterModeGroups:
+		f, err := filter.SavedFilterToMovieFilter(ctx, savedFilter)
+		if err != nil {
+			return nil, fmt.Errorf("SavedFilterToMovieFilter: %w", err)
+		}
+		if err := checkUnsupported(d, f.Unsupported); err != nil {
+			return nil, err
+		}
+		response, err := gql.FindMoviesByFilter(ctx, client, &f.MovieFilter, &f.FilterOpts)
+		if err != nil {
+			return nil, fmt.Errorf("FindMoviesByFilter: %w", err)
+		}
+		for _, m := range response.FindMovies.Movies {
+			objects = append(objects, sceneObject{id: m.Id, name: m.Name})
+		}
+	default:
+		return nil, errUnsupportedMode
+	}
+	return objects, nil
+}
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"stash-vr/internal/logger"
+	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash/filter/query"
+	"stash-vr/internal/stash/gql"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// sectionFromQuery builds a section of the scenes matched by an ad-hoc query, named after the query unless overridden.
+func sectionFromQuery(ctx context.Context, client graphql.Client, prefix string, expr string, overrides Overrides) (section.Section, error) {
+	f, err := query.Compile(ctx, expr, query.StashResolver(client))
+	if err != nil {
+		return section.Section{}, fmt.Errorf("query '%s': %w", expr, err)
+	}
+	if overrides.Limit > 0 {
+		f.FilterOpts.Per_page = overrides.Limit
+	}
+	if overrides.Sort != "" {
+		f.FilterOpts.Sort = overrides.Sort
+	}
+	if overrides.Direction != "" {
+		f.FilterOpts.Direction = overrides.Direction
+	}
+	name := overrides.Name
+	if name == "" {
+		name = expr
+	}
+
+	start := time.Now()
+	scenesResponse, err := gql.FindScenePreviewsByFilter(ctx, client, &f.SceneFilter, &f.FilterOpts)
+	if err != nil {
+		return section.Section{}, fmt.Errorf("FindScenePreviewsByFilter query='%s' parsedFilter=%+v: %w", expr, logger.AsJsonStr(f), err)
+	}
+
+	s := section.Section{
+		Name:             prefix + name,
+		FilterId:         "query:" + expr,
+		PreviewPartsList: make([]gql.ScenePreviewParts, len(scenesResponse.FindScenes.Scenes)),
+		BuiltAt:          start,
+		FilterDuration:   time.Since(start),
+	}
+	for i, v := range scenesResponse.FindScenes.Scenes {
+		s.PreviewPartsList[i] = v.ScenePreviewParts
+	}
+	return s, nil
+}
@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"stash-vr/internal/config"
+	"stash-vr/internal/profile"
+	"stash-vr/internal/recommend"
+	"stash-vr/internal/sections/definition"
+	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash"
+	"stash-vr/internal/stash/gql"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	recommendedName         = "Recommended for you"
+	recommendedDefaultLimit = 25
+	// recommendedSeeds is how many of the most recently updated favourites and highly rated scenes seed the recommendations.
+	recommendedSeeds = 20
+	// recommendedMinRating100 is the rating from which a scene is considered highly rated.
+	recommendedMinRating100 = 80
+)
+
+// sectionRecommended ranks the library by similarity to recently favourited or highly rated scenes.
+// Favourites are scenes with the global favorite tag, sections are shared by profiles with the same filters.
+func sectionRecommended(ctx context.Context, client graphql.Client, prefix string, d definition.Definition) ([]section.Section, error) {
+	ctx = sourceLogContext(ctx, d.Source)
+
+	limit := d.Limit
+	if limit == 0 {
+		limit = recommendedDefaultLimit
+	}
+
+	start := time.Now()
+	library, err := stash.FindAllSceneScans(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("FindAllSceneScans: %w", err)
+	}
+
+	seeds := recommendationSeeds(library)
+	if len(seeds) == 0 {
+		log.Ctx(ctx).Debug().Msg("Section skipped: no favourite or highly rated scenes")
+		return nil, nil
+	}
+
+	items := make([]recommend.Item, len(library))
+	byId := make(map[string]*stash.LibraryScene, len(library))
+	for i, s := range library {
+		items[i] = recommendationItem(s)
+		byId[s.SceneScanParts.Id] = s
+	}
+	recommended := recommend.New(items).Recommend(seeds, limit)
+	if len(recommended) == 0 {
+		log.Ctx(ctx).Debug().Msg("Section skipped: 0 scenes")
+		return nil, nil
+	}
+
+	name := d.Name
+	if name == "" {
+		name = recommendedName
+	}
+	s := section.Section{
+		Name:             prefix + name,
+		FilterId:         definition.SourceRecommended,
+		PreviewPartsList: make([]gql.ScenePreviewParts, len(recommended)),
+		BuiltAt:          start,
+		FilterDuration:   time.Since(start),
+	}
+	for i, r := range recommended {
+		s.PreviewPartsList[i] = byId[r.Id].ScenePreviewParts
+	}
+	log.Ctx(ctx).Debug().Int("seeds", len(seeds)).Int("scenes", len(recommended)).Msg("Recommendations built")
+	return []section.Section{s}, nil
+}
+
+// recommendationSeeds returns the ids of the most recently updated favourite or highly rated scenes.
+// Tagging or rating a scene updates it, so these are the ones most recently favourited or rated.
+func recommendationSeeds(library []*stash.LibraryScene) []string {
+	var candidates []*stash.LibraryScene
+	for _, s := range library {
+		if s.SceneScanParts.Rating100 >= recommendedMinRating100 || hasTag(s, config.Get().FavoriteTag) {
+			candidates = append(candidates, s)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Updated_at.After(candidates[j].Updated_at)
+	})
+	if len(candidates) > recommendedSeeds {
+		candidates = candidates[:recommendedSeeds]
+	}
+	seeds := make([]string, len(candidates))
+	for i, s := range candidates {
+		seeds[i] = s.SceneScanParts.Id
+	}
+	return seeds
+}
+
+// recommendationItem leaves out favorite tags, they say nothing about the content.
+func recommendationItem(s *stash.LibraryScene) recommend.Item {
+	item := recommend.Item{Id: s.SceneScanParts.Id}
+	for _, t := range s.Tags {
+		if !profile.IsFavoriteTag(t.Name) {
+			item.Tags = append(item.Tags, t.Id)
+		}
+	}
+	for _, p := range s.Performers {
+		item.Performers = append(item.Performers, p.Id)
+	}
+	if s.Studio != nil {
+		item.Studio = s.Studio.Id
+	}
+	return item
+}
+
+func hasTag(s *stash.LibraryScene, name string) bool {
+	for _, t := range s.Tags {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
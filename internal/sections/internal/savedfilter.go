@@ -4,54 +4,146 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"stash-vr/internal/config"
 	"stash-vr/internal/logger"
+	"stash-vr/internal/sections/diagnostic"
 	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash"
 	"stash-vr/internal/stash/filter"
 	"stash-vr/internal/stash/gql"
 	"stash-vr/internal/util"
 	"strings"
+	"time"
 
 	"github.com/Khan/genqlient/graphql"
 	"github.com/rs/zerolog/log"
 )
 
-type sectionFromSavedFilterFunc = util.Transform[gql.SavedFilterParts, section.Section]
+type sectionsFromSavedFilterFunc = util.Transform[gql.SavedFilterParts, []section.Section]
 
 var errNoScenesFound = errors.New("no scenes found")
 
-func sectionFromSavedFilterFuncBuilder(ctx context.Context, client graphql.Client, prefix string, source string) sectionFromSavedFilterFunc {
-	return func(savedFilter gql.SavedFilterParts) (section.Section, error) {
+// Overrides adjust a section built from a saved filter, zero values keep the saved filter's settings.
+type Overrides struct {
+	Name      string
+	Limit     int
+	Sort      string
+	Direction gql.SortDirectionEnum
+}
+
+// errPartialFilter refuses filters with unsupported criteria when STRICT_FILTERS is set.
+var errPartialFilter = errors.New("partially translated filter refused by STRICT_FILTERS")
+
+// sectionsFromSavedFilterFuncBuilder builds a section of a saved scene or marker filter, or a section per performer,
+// studio or movie of a saved filter of that mode. The outcome is recorded as the filter's diagnostic.
+func sectionsFromSavedFilterFuncBuilder(ctx context.Context, client graphql.Client, prefix string, source string, overrides Overrides) sectionsFromSavedFilterFunc {
+	return func(savedFilter gql.SavedFilterParts) ([]section.Section, error) {
 		ctx := sourceLogContext(filterLogContext(ctx, savedFilter), source)
-		s, err := sectionFromSavedFilter(ctx, client, prefix, savedFilter)
+		d := diagnostic.Diagnostic{
+			FilterId:   savedFilter.Id,
+			FilterName: savedFilter.Name,
+			Mode:       string(savedFilter.Mode),
+			Source:     source,
+		}
+		defer func() { diagnostic.Record(ctx, d) }()
+
+		if savedFilter.Mode != gql.FilterModeScenes && savedFilter.Mode != gql.FilterModeSceneMarkers {
+			ss, err := sectionsFromObjectFilter(ctx, client, prefix, savedFilter, overrides, &d)
+			if err != nil {
+				d.Error = err.Error()
+				log.Ctx(ctx).Warn().Err(err).Msg("Filter skipped")
+				return nil, err
+			}
+			for _, s := range ss {
+				d.Scenes += s.Len()
+			}
+			if len(ss) == 0 {
+				log.Ctx(ctx).Debug().Msg("Filter skipped: 0 scenes")
+				return nil, errNoScenesFound
+			}
+			log.Ctx(ctx).Debug().Int("sections", len(ss)).Msg("Sections built")
+			return ss, nil
+		}
+		var s section.Section
+		var err error
+		if savedFilter.Mode == gql.FilterModeSceneMarkers {
+			s, err = sectionFromMarkerFilter(ctx, client, prefix, savedFilter, overrides, &d)
+		} else {
+			s, err = sectionFromSavedFilter(ctx, client, prefix, savedFilter, overrides, &d)
+		}
 		if err != nil {
+			d.Error = err.Error()
 			log.Ctx(ctx).Warn().Err(err).Msg("Filter skipped")
-			return section.Section{}, err
+			return nil, err
 		}
-		if len(s.PreviewPartsList) == 0 {
+		d.Scenes = s.Len()
+		if s.Len() == 0 {
 			log.Ctx(ctx).Debug().Msg("Filter skipped: 0 scenes")
-			return section.Section{}, errNoScenesFound
+			return nil, errNoScenesFound
 		}
 		ctx = sectionLogContext(ctx, s)
 		log.Ctx(ctx).Debug().Msg("Section built")
-		return s, nil
+		return []section.Section{s}, nil
+	}
+}
+
+// checkUnsupported records the criteria left out of a translated filter, with STRICT_FILTERS they refuse the filter.
+func checkUnsupported(d *diagnostic.Diagnostic, unsupported []string) error {
+	d.Unsupported = unsupported
+	if len(unsupported) > 0 && config.Get().IsStrictFilters {
+		d.Refused = true
+		return fmt.Errorf("%w: unsupported criteria %s", errPartialFilter, strings.Join(unsupported, ", "))
+	}
+	return nil
+}
+
+func flatten(sectionLists [][]section.Section) []section.Section {
+	var sections []section.Section
+	for _, ss := range sectionLists {
+		sections = append(sections, ss...)
 	}
+	return sections
 }
 
-func sectionFromSavedFilter(ctx context.Context, client graphql.Client, prefix string, savedFilter gql.SavedFilterParts) (section.Section, error) {
+func sectionFromSavedFilter(ctx context.Context, client graphql.Client, prefix string, savedFilter gql.SavedFilterParts, overrides Overrides, d *diagnostic.Diagnostic) (section.Section, error) {
 	filterQuery, err := filter.SavedFilterToSceneFilter(ctx, savedFilter)
 	if err != nil {
 		return section.Section{}, fmt.Errorf("SavedFilterToSceneFilter: %w", err)
 	}
+	if err := checkUnsupported(d, filterQuery.Unsupported); err != nil {
+		return section.Section{}, err
+	}
+	if overrides.Limit > 0 {
+		filterQuery.FilterOpts.Per_page = overrides.Limit
+	}
+	if overrides.Sort != "" {
+		filterQuery.FilterOpts.Sort = overrides.Sort
+	}
+	if overrides.Direction != "" {
+		filterQuery.FilterOpts.Direction = overrides.Direction
+	}
+	if overrides.Name != "" {
+		savedFilter.Name = overrides.Name
+	}
 
+	start := time.Now()
 	scenesResponse, err := gql.FindScenePreviewsByFilter(ctx, client, &filterQuery.SceneFilter, &filterQuery.FilterOpts)
 	if err != nil {
 		return section.Section{}, fmt.Errorf("FindScenePreviewsByFilter savedFilter=%+v parsedFilter=%+v: %w", savedFilter.Object_filter, logger.AsJsonStr(filterQuery), err)
 	}
+	d.Count = &scenesResponse.FindScenes.Count
+	if count, err := stashCount(ctx, client, savedFilter); err != nil {
+		log.Ctx(ctx).Debug().Err(err).Msg("Stash count of saved filter unavailable")
+	} else {
+		d.StashCount = &count
+	}
 
 	s := section.Section{
 		Name:             getSectionName(prefix, savedFilter),
 		FilterId:         savedFilter.Id,
 		PreviewPartsList: make([]gql.ScenePreviewParts, len(scenesResponse.FindScenes.Scenes)),
+		BuiltAt:          start,
+		FilterDuration:   time.Since(start),
 	}
 
 	for i, v := range scenesResponse.FindScenes.Scenes {
@@ -60,6 +152,20 @@ func sectionFromSavedFilter(ctx context.Context, client graphql.Client, prefix s
 	return s, nil
 }
 
+// stashCount returns the number of scenes Stash counts for the criteria of savedFilter as they are, to compare with
+// those of the translated filter.
+func stashCount(ctx context.Context, client graphql.Client, savedFilter gql.SavedFilterParts) (int, error) {
+	sceneFilter, err := filter.RawSceneFilter(savedFilter.Object_filter)
+	if err != nil {
+		return 0, fmt.Errorf("RawSceneFilter: %w", err)
+	}
+	var q string
+	if savedFilter.Find_filter != nil {
+		q = savedFilter.Find_filter.Q
+	}
+	return stash.CountScenesByRawFilter(ctx, client, sceneFilter, q)
+}
+
 func getSectionName(prefix string, fp gql.SavedFilterParts) string {
 	var sb strings.Builder
 	sb.WriteString(prefix)
@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"stash-vr/internal/sections/definition"
+	"stash-vr/internal/sections/section"
+	"strings"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// SectionsBySources builds sections from a list of sources, in order. A source is either one of definition.Sources
+// or a saved filter id. An empty list means definition.SourceAll.
+func SectionsBySources(ctx context.Context, client graphql.Client, prefix string, sources []string) ([]section.Section, error) {
+	var sections []section.Section
+	var filterIds []string
+
+	flushFilterIds := func() error {
+		if len(filterIds) == 0 {
+			return nil
+		}
+		ss, err := SectionsByFilterIds(ctx, client, prefix, filterIds)
+		if err != nil {
+			return fmt.Errorf("SectionsByFilterIds: %w", err)
+		}
+		sections = append(sections, ss...)
+		filterIds = nil
+		return nil
+	}
+
+	isEmpty := true
+	for _, source := range sources {
+		source = strings.ToLower(strings.TrimSpace(source))
+		if source == "" {
+			continue
+		}
+		isEmpty = false
+
+		if err := flushFilterIds(); err != nil {
+			return nil, err
+		}
+		if !definition.IsSource(source) {
+			filterIds = append(filterIds, source)
+			continue
+		}
+		ss, err := sectionsBySource(ctx, client, prefix, definition.Definition{Source: source})
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, ss...)
+	}
+	if err := flushFilterIds(); err != nil {
+		return nil, err
+	}
+
+	if isEmpty {
+		return SectionsBySavedFilters(ctx, client, prefix)
+	}
+	return sections, nil
+}
+
+// sectionsBySource builds the sections of d.Source using the options of d that apply to sources.
+func sectionsBySource(ctx context.Context, client graphql.Client, prefix string, d definition.Definition) ([]section.Section, error) {
+	var ss []section.Section
+	var err error
+	switch d.Source {
+	case definition.SourceFrontpage:
+		ss, err = SectionsByFrontpage(ctx, client, prefix)
+	case definition.SourceAll:
+		ss, err = SectionsBySavedFilters(ctx, client, prefix)
+	case definition.SourceTags, definition.SourceStudios, definition.SourcePerformers, definition.SourceMarkerTags:
+		ss, err = sectionsByAuto(ctx, client, prefix, d)
+	case definition.SourceContinueWatching, definition.SourceRecentlyPlayed, definition.SourceMostPlayed:
+		ss, err = sectionsByPlayHistory(ctx, client, prefix, d)
+	case definition.SourceDiscover:
+		ss, err = sectionDiscover(ctx, client, prefix, d)
+	case definition.SourceRecommended:
+		ss, err = sectionRecommended(ctx, client, prefix, d)
+	case definition.SourceFolders:
+		ss, err = sectionsByFolders(ctx, client, prefix, d)
+	default:
+		return nil, fmt.Errorf("unknown source '%s'", d.Source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("source '%s': %w", d.Source, err)
+	}
+	if d.Limit > 0 {
+		for i := range ss {
+			if ss[i].Len() > d.Limit {
+				ss[i] = ss[i].Slice(0, d.Limit)
+			}
+		}
+	}
+	return ss, nil
+}
@@ -0,0 +1,133 @@
+package sections
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"stash-vr/internal/config"
+	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash/gql"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	diskCacheFileName = "sections-cache.json"
+	// diskCacheVersion must be incremented whenever the persisted types change, files of other versions are discarded.
+	diskCacheVersion = 5
+)
+
+type diskEntry[T any] struct {
+	BuiltAt time.Time `json:"built_at"`
+	Value   T         `json:"value"`
+}
+
+// diskCache holds the last built sections and scan data, keyed by profile filters, so they can be served right after a restart.
+type diskCache struct {
+	Version  int                                         `json:"version"`
+	Sections map[string]diskEntry[[]section.Section]     `json:"sections"`
+	Scans    map[string]diskEntry[[]*gql.SceneScanParts] `json:"scans"`
+}
+
+var disk struct {
+	mu     sync.Mutex
+	loaded bool
+	data   diskCache
+}
+
+func diskCachePath() string {
+	return filepath.Join(config.Get().DataDir, diskCacheFileName)
+}
+
+// loadDisk must be called with disk.mu held.
+func loadDisk() {
+	if disk.loaded {
+		return
+	}
+	disk.loaded = true
+	disk.data = diskCache{Version: diskCacheVersion}
+
+	var d diskCache
+	b, err := os.ReadFile(diskCachePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("path", diskCachePath()).Msg("Failed to read sections cache")
+		return
+	}
+	if err := json.Unmarshal(b, &d); err != nil {
+		log.Warn().Err(err).Str("path", diskCachePath()).Msg("Failed to parse sections cache, discarding")
+		return
+	}
+	if d.Version != diskCacheVersion {
+		log.Info().Int("version", d.Version).Int("expected", diskCacheVersion).Msg("Sections cache is of another version, discarding")
+		return
+	}
+	disk.data = d
+}
+
+// saveDisk must be called with disk.mu held.
+func saveDisk() error {
+	b, err := json.Marshal(disk.data)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	if err := os.MkdirAll(config.Get().DataDir, 0700); err != nil {
+		return fmt.Errorf("create dir: %w", err)
+	}
+	tmp := diskCachePath() + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	if err := os.Rename(tmp, diskCachePath()); err != nil {
+		return fmt.Errorf("rename: %w", err)
+	}
+	return nil
+}
+
+func restoreSections(filters string) (diskEntry[[]section.Section], bool) {
+	disk.mu.Lock()
+	defer disk.mu.Unlock()
+	loadDisk()
+	e, ok := disk.data.Sections[filters]
+	return e, ok
+}
+
+func restoreScans(filters string) (diskEntry[[]*gql.SceneScanParts], bool) {
+	disk.mu.Lock()
+	defer disk.mu.Unlock()
+	loadDisk()
+	e, ok := disk.data.Scans[filters]
+	return e, ok
+}
+
+func persistSections(filters string, sections []section.Section) {
+	disk.mu.Lock()
+	defer disk.mu.Unlock()
+	loadDisk()
+	if disk.data.Sections == nil {
+		disk.data.Sections = make(map[string]diskEntry[[]section.Section])
+	}
+	disk.data.Sections[filters] = diskEntry[[]section.Section]{BuiltAt: time.Now(), Value: sections}
+	if err := saveDisk(); err != nil {
+		log.Warn().Err(err).Str("path", diskCachePath()).Msg("Failed to save sections cache")
+	}
+}
+
+func persistScans(filters string, scans []*gql.SceneScanParts) {
+	disk.mu.Lock()
+	defer disk.mu.Unlock()
+	loadDisk()
+	if disk.data.Scans == nil {
+		disk.data.Scans = make(map[string]diskEntry[[]*gql.SceneScanParts])
+	}
+	disk.data.Scans[filters] = diskEntry[[]*gql.SceneScanParts]{BuiltAt: time.Now(), Value: scans}
+	if err := saveDisk(); err != nil {
+		log.Warn().Err(err).Str("path", diskCachePath()).Msg("Failed to save sections cache")
+	}
+}
@@ -0,0 +1,78 @@
+package sections
+
+import (
+	"context"
+	"fmt"
+	"stash-vr/internal/cache"
+	"stash-vr/internal/profile"
+	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash/gql"
+	"strconv"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/rs/zerolog/log"
+)
+
+// scansCache holds the scan data of all scenes in the sections, keyed like sectionsCache.
+var scansCache = cache.New[string, []*gql.SceneScanParts]("scans", cache.Options{TTL: ttl})
+
+// Scans returns the scan data of all scenes in the sections of the profile in ctx.
+func Scans(ctx context.Context, client graphql.Client) []*gql.SceneScanParts {
+	filters := profile.FromContext(ctx).Filters
+	if !scansCache.Contains(filters) {
+		if e, ok := restoreScans(filters); ok {
+			scansCache.Set(filters, e.Value, e.BuiltAt)
+		}
+	}
+	scans, err := scansCache.Get(ctx, filters, fetchScans(client, filters))
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Failed to build scan data")
+		return nil
+	}
+	return scans
+}
+
+func fetchScans(client graphql.Client, filters string) func(ctx context.Context) ([]*gql.SceneScanParts, error) {
+	return func(ctx context.Context) ([]*gql.SceneScanParts, error) {
+		scans, err := buildScans(ctx, client, filters)
+		if err != nil {
+			return nil, err
+		}
+		persistScans(filters, scans)
+		return scans, nil
+	}
+}
+
+func buildScans(ctx context.Context, client graphql.Client, filters string) ([]*gql.SceneScanParts, error) {
+	ss, err := sectionsCache.Get(ctx, filters, fetchSections(client, filters))
+	if err != nil {
+		return nil, fmt.Errorf("sections: %w", err)
+	}
+	return scansOfSections(ctx, client, ss)
+}
+
+// scansOfSections fetches the scan data of the scenes in ss.
+func scansOfSections(ctx context.Context, client graphql.Client, ss []section.Section) ([]*gql.SceneScanParts, error) {
+	sceneIdMap := make(map[int]any)
+	for _, s := range ss {
+		for _, preview := range s.PreviewPartsList {
+			id, _ := strconv.Atoi(preview.Id)
+			sceneIdMap[id] = struct{}{}
+		}
+	}
+	sceneIds := make([]int, 0, len(sceneIdMap))
+	for id := range sceneIdMap {
+		sceneIds = append(sceneIds, id)
+	}
+
+	response, err := gql.FindSceneScansByIds(ctx, client, sceneIds)
+	if err != nil {
+		return nil, fmt.Errorf("FindSceneScansByIds: %w", err)
+	}
+
+	scans := make([]*gql.SceneScanParts, len(response.FindScenes.Scenes))
+	for i, s := range response.FindScenes.Scenes {
+		scans[i] = &s.SceneScanParts
+	}
+	return scans, nil
+}
@@ -2,17 +2,71 @@ package section
 
 import (
 	"stash-vr/internal/stash/gql"
+	"time"
 )
 
 type Section struct {
 	Name             string
 	FilterId         string
 	PreviewPartsList []gql.ScenePreviewParts
+	// Markers are the entries of a marker section, which has no PreviewPartsList.
+	Markers []Marker
+	// BuiltAt is when the scenes were queried from Stash and FilterDuration how long the query took.
+	BuiltAt        time.Time
+	FilterDuration time.Duration
+	// Player restricts the section to one player, empty for all.
+	Player string
+	// PageSize splits the section into pages of this size once built, 0 for the global setting.
+	PageSize int `json:"-"`
 }
 
-func ContainsFilterId(id string, list []Section) bool {
+// Marker is a scene marker, played as its scene starting at Seconds.
+type Marker struct {
+	Id         string
+	Title      string
+	Seconds    float64
+	Screenshot string
+	Scene      gql.ScenePreviewParts
+}
+
+// MarkerTitle is the title of a marker entry, e.g. "Tag: Title", or the tag alone if the marker has no title.
+func MarkerTitle(tag string, title string) string {
+	if title == "" {
+		return tag
+	}
+	return tag + ": " + title
+}
+
+// Len returns the number of entries, scenes or markers, of s.
+func (s Section) Len() int {
+	return len(s.PreviewPartsList) + len(s.Markers)
+}
+
+// Slice returns s with only the entries from start up to end.
+func (s Section) Slice(start int, end int) Section {
+	if len(s.Markers) > 0 {
+		s.Markers = s.Markers[start:end]
+	} else {
+		s.PreviewPartsList = s.PreviewPartsList[start:end]
+	}
+	return s
+}
+
+// ForPlayer returns the sections to show in player.
+func ForPlayer(player string, list []Section) []Section {
+	sections := make([]Section, 0, len(list))
+	for _, s := range list {
+		if s.Player == "" || s.Player == player {
+			sections = append(sections, s)
+		}
+	}
+	return sections
+}
+
+// ContainsDuplicate reports whether list has a section of the same filter, name and player as s.
+func ContainsDuplicate(s Section, list []Section) bool {
 	for _, v := range list {
-		if id == v.FilterId {
+		if s.FilterId == v.FilterId && s.Name == v.Name && s.Player == v.Player {
 			return true
 		}
 	}
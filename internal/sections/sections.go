@@ -3,58 +3,217 @@ package sections
 import (
 	"bufio"
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"stash-vr/internal/cache"
 	"stash-vr/internal/config"
+	"stash-vr/internal/profile"
+	"stash-vr/internal/sections/definition"
+	"stash-vr/internal/sections/diagnostic"
 	"stash-vr/internal/sections/internal"
 	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash/compat"
+	"stash-vr/internal/stash/gql"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/Khan/genqlient/graphql"
 	"github.com/rs/zerolog/log"
 )
 
-var c cache.Cache[[]section.Section]
+// ttl is how long built sections are served before rebuilt in the background on request.
+// Changes in Stash are usually picked up earlier through the watcher.
+const ttl = 5 * time.Minute
 
+// sectionsCache holds the sections per filter list, profiles without own filters share the default.
+var sectionsCache = cache.New[string, []section.Section]("sections", cache.Options{TTL: ttl})
+
+// Get returns the sections of the profile in ctx.
 func Get(ctx context.Context, client graphql.Client) []section.Section {
-	return c.Get(ctx, func(ctx context.Context) []section.Section {
-		return build(ctx, client, config.Get().Filters)
-	})
+	filters := profile.FromContext(ctx).Filters
+	if !sectionsCache.Contains(filters) {
+		if e, ok := restoreSections(filters); ok {
+			log.Ctx(ctx).Info().Time("built", e.BuiltAt).Int("sections", len(e.Value)).Msg("Serving sections from disk cache until rebuilt")
+			sectionsCache.Set(filters, e.Value, e.BuiltAt)
+			go refresh(backgroundContext(ctx), client, filters)
+		}
+	}
+	ss, err := sectionsCache.Get(ctx, filters, fetchSections(client, filters))
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Failed to build sections")
+		return nil
+	}
+	return ss
 }
 
-func build(ctx context.Context, client graphql.Client, filters string) []section.Section {
-	ss := []section.Section{}
-	readFile, err := os.Open("sections.txt")
+// Refresh rebuilds the sections and scan data of every profile requested so far, players are served the previous data meanwhile.
+func Refresh(ctx context.Context, client graphql.Client) {
+	adHocCache.InvalidateAll()
+	adHocScansCache.InvalidateAll()
+	diagnostic.ResetWhere(func(build string) bool { return strings.HasPrefix(build, adHocDiagnosticBuildPrefix) })
+	for _, filters := range sectionsCache.Keys() {
+		refresh(ctx, client, filters)
+	}
+}
 
-	if err != nil {
-		filterIds := strings.Split(filters, ",")
-		ss, err = internal.SectionsByFilterIds(ctx, client, "", filterIds)
-	} else {
-		filterNames := []string{}
-		fileScanner := bufio.NewScanner(readFile)
-		fileScanner.Split(bufio.ScanLines)
-
-		for fileScanner.Scan() {
-			filterName := fileScanner.Text()
-			if filterName != "" {
-				filterNames = append(filterNames, filterName)
+func refresh(ctx context.Context, client graphql.Client, filters string) {
+	if _, err := sectionsCache.Refresh(ctx, filters, fetchSections(client, filters)); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Failed to rebuild sections, keeping previous")
+	}
+	if scansCache.Contains(filters) {
+		if _, err := scansCache.Refresh(ctx, filters, fetchScans(client, filters)); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("Failed to rebuild scan data, keeping previous")
+		}
+	}
+}
+
+// RefreshPlayHistory requeries, in the background, the play history sections of every profile whose sections are built.
+// Called when a play is written to Stash so that Continue Watching and the like don't wait for the next rebuild.
+func RefreshPlayHistory(ctx context.Context, client graphql.Client) {
+	go func() {
+		ctx := backgroundContext(ctx)
+		for _, filters := range sectionsCache.Keys() {
+			// invalidated meanwhile, a play isn't worth a full build
+			if !sectionsCache.Contains(filters) {
+				continue
+			}
+			fetchedAt := sectionsCache.FetchedAt(filters)
+			ss, err := sectionsCache.Get(ctx, filters, fetchSections(client, filters))
+			if err != nil || !containsPlayHistory(ss) {
+				continue
+			}
+			ss, err = refreshPlayHistory(ctx, client, ss)
+			if err != nil {
+				log.Ctx(ctx).Warn().Err(err).Msg("Failed to refresh play history sections, keeping previous")
+				continue
+			}
+			// a full rebuild meanwhile has newer play history
+			if sectionsCache.FetchedAt(filters).Equal(fetchedAt) {
+				sectionsCache.Set(filters, ss, fetchedAt)
+			}
+		}
+	}()
+}
+
+func containsPlayHistory(ss []section.Section) bool {
+	for _, s := range ss {
+		if internal.IsPlayHistory(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshPlayHistory returns ss with the scenes of its play history sections requeried. A section keeps its number of
+// scenes, split over its pages in order, so caps and pages stay as built.
+func refreshPlayHistory(ctx context.Context, client graphql.Client, ss []section.Section) ([]section.Section, error) {
+	counts := make(map[string]int)
+	for _, s := range ss {
+		if internal.IsPlayHistory(s) {
+			counts[s.FilterId] += s.Len()
+		}
+	}
+	scenes := make(map[string][]gql.ScenePreviewParts, len(counts))
+	for filterId, count := range counts {
+		found, err := internal.PlayHistoryScenes(ctx, client, filterId, count)
+		if err != nil {
+			return nil, err
+		}
+		scenes[filterId] = found
+	}
+
+	now := time.Now()
+	refreshed := make([]section.Section, len(ss))
+	for i, s := range ss {
+		if internal.IsPlayHistory(s) {
+			remaining := scenes[s.FilterId]
+			n := s.Len()
+			if n > len(remaining) {
+				n = len(remaining)
 			}
+			s.PreviewPartsList, scenes[s.FilterId] = remaining[:n], remaining[n:]
+			s.BuiltAt = now
+		}
+		refreshed[i] = s
+	}
+	return refreshed, nil
+}
+
+// DiscoverSeed is the seed of the random sort of the discover section.
+type DiscoverSeed = internal.DiscoverSeed
+
+// CurrentDiscoverSeed returns the seed the discover section is built with today.
+func CurrentDiscoverSeed() DiscoverSeed {
+	return internal.CurrentDiscoverSeed()
+}
+
+// RotateDiscover replaces the seed of the discover section and rebuilds the sections.
+func RotateDiscover(ctx context.Context, client graphql.Client) DiscoverSeed {
+	seed := internal.RotateDiscoverSeed()
+	log.Ctx(ctx).Info().Int64("seed", seed.Seed).Msg("Discover seed rotated")
+	Refresh(ctx, client)
+	return seed
+}
+
+// UpdatedAt returns when the sections of the profile in ctx were built, zero if not yet.
+func UpdatedAt(ctx context.Context) time.Time {
+	return sectionsCache.FetchedAt(profile.FromContext(ctx).Filters)
+}
+
+func backgroundContext(ctx context.Context) context.Context {
+	return log.Ctx(ctx).With().Str("op", "bg").Logger().WithContext(context.Background())
+}
+
+func fetchSections(client graphql.Client, filters string) func(ctx context.Context) ([]section.Section, error) {
+	return func(ctx context.Context) ([]section.Section, error) {
+		ss, err := build(ctx, client, filters)
+		if err != nil {
+			return nil, err
 		}
-		readFile.Close()
+		persistSections(filters, ss)
+		return ss, nil
+	}
+}
 
-		ss, err = internal.SectionsByFilterName(ctx, client, "", filterNames)
+// diagnosticBuild names the build of the sections of a filter list in the filter diagnostics.
+func diagnosticBuild(filters string) string {
+	if filters == "" {
+		return "sections"
+	}
+	return "sections of " + filters
+}
+
+func build(ctx context.Context, client graphql.Client, profileFilters string) ([]section.Section, error) {
+	var ss []section.Section
+	var err error
+
+	// filters no longer built are left out of the diagnostics
+	diagnostic.Reset(diagnosticBuild(profileFilters))
+	ctx = diagnostic.WithBuild(ctx, diagnosticBuild(profileFilters))
+
+	if !compat.IsDetected() {
+		if _, err := compat.Detect(ctx, client); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("Failed to detect stash version, using that of the bundled schema")
+		}
+	}
+
+	if profileFilters != "" {
+		ss, err = internal.SectionsBySources(ctx, client, "", strings.Split(profileFilters, ","))
+	} else {
+		ss, err = buildDefault(ctx, client)
 	}
 
 	if err != nil {
-		log.Ctx(ctx).Warn().Err(err).Msg("Failed to build sections by filter ids")
-		return nil
+		return nil, fmt.Errorf("build sections: %w", err)
 	}
-	log.Ctx(ctx).Debug().Int("count", len(ss)).Msg("Sections built from filter list")
+	log.Ctx(ctx).Debug().Int("count", len(ss)).Msg("Sections built from sources")
 
 	var sections []section.Section
 
 	for _, s := range ss {
-		if s.FilterId != "" && section.ContainsFilterId(s.FilterId, sections) {
+		if s.FilterId != "" && section.ContainsDuplicate(s, sections) {
 			log.Ctx(ctx).Trace().Str("filterId", s.FilterId).Str("section", s.Name).Msg("Filter already added, skipping")
 			continue
 		}
@@ -65,23 +224,80 @@ func build(ctx context.Context, client graphql.Client, filters string) []section
 		log.Ctx(ctx).Info().Msg("No scenes found using current filters. Adding a default section with all scenes.")
 		s, err := internal.SectionWithAllScenes(ctx, client)
 		if err != nil {
-			log.Ctx(ctx).Warn().Err(err).Msg("Failed to build custom section with all scenes")
+			return nil, fmt.Errorf("build custom section with all scenes: %w", err)
+		}
+		if len(s.PreviewPartsList) == 0 {
+			log.Ctx(ctx).Info().Msg("No scenes found in Stash.")
 		} else {
-			if len(s.PreviewPartsList) == 0 {
-				log.Ctx(ctx).Info().Msg("No scenes found in Stash.")
-			} else {
-				sections = append(sections, s)
-			}
+			sections = append(sections, s)
 		}
 	}
 
+	sections = capsFromConfig().apply(ctx, sections)
+
 	count := Count(sections)
 
 	if count.Links > 10000 {
-		log.Ctx(ctx).Warn().Int("links", count.Links).Msg("More than 10.000 links generated. Known to cause issues with video players.")
+		log.Ctx(ctx).Warn().Int("links", count.Links).Msg("More than 10.000 links generated. Known to cause issues with video players. Consider MAX_LINKS or SECTION_MAX_SCENES.")
 	}
 
 	log.Ctx(ctx).Info().Int("sections", len(sections)).Int("links", count.Links).Int("scenes", count.Scenes).Msg("Sections build complete")
 
-	return sections
+	return sections, nil
+}
+
+// buildDefault builds sections by the sections file if present, otherwise by the filter names in sections.txt
+// if present, otherwise by FILTERS.
+func buildDefault(ctx context.Context, client graphql.Client) ([]section.Section, error) {
+	definitions, err := definition.Load(config.Get().SectionsFile)
+	setFileProblems(err)
+	if err == nil {
+		return internal.SectionsByDefinitions(ctx, client, definitions)
+	}
+	if !definition.IsNotExist(err) {
+		return nil, fmt.Errorf("sections file: %w", err)
+	}
+
+	readFile, err := os.Open("sections.txt")
+	if err != nil {
+		return internal.SectionsBySources(ctx, client, "", strings.Split(config.Get().Filters, ","))
+	}
+	defer readFile.Close()
+
+	filterNames := []string{}
+	fileScanner := bufio.NewScanner(readFile)
+	fileScanner.Split(bufio.ScanLines)
+
+	for fileScanner.Scan() {
+		filterName := fileScanner.Text()
+		if filterName != "" {
+			filterNames = append(filterNames, filterName)
+		}
+	}
+
+	return internal.SectionsByFilterName(ctx, client, "", filterNames)
+}
+
+var fileProblems struct {
+	mu       sync.Mutex
+	problems []string
+}
+
+func setFileProblems(err error) {
+	fileProblems.mu.Lock()
+	defer fileProblems.mu.Unlock()
+	fileProblems.problems = nil
+	var definitionErrors *definition.Errors
+	if errors.As(err, &definitionErrors) {
+		fileProblems.problems = definitionErrors.Problems
+	} else if err != nil && !definition.IsNotExist(err) {
+		fileProblems.problems = []string{err.Error()}
+	}
+}
+
+// FileProblems returns the problems found in the sections file when last loaded.
+func FileProblems() []string {
+	fileProblems.mu.Lock()
+	defer fileProblems.mu.Unlock()
+	return fileProblems.problems
 }
@@ -0,0 +1,117 @@
+package sections
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"stash-vr/internal/sections/definition"
+	"stash-vr/internal/sections/internal"
+	"stash-vr/internal/sections/section"
+	"stash-vr/internal/stash/gql"
+	"testing"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// fakeStash answers a saved scene filter by id and the scenes of any scene filter, limited by per_page.
+type fakeStash struct {
+	scenes int
+}
+
+func (f fakeStash) MakeRequest(_ context.Context, req *graphql.Request, resp *graphql.Response) error {
+	var data string
+	switch req.OpName {
+	case "FindSavedFilter":
+		data = `{"findSavedFilter": {"id": "1", "name": "POV", "mode": "SCENES", "find_filter": {"sort": "date", "direction": "ASC"}, "object_filter": {}}}`
+	case "FindScenePreviewsByFilter":
+		b, err := json.Marshal(req.Variables)
+		if err != nil {
+			return err
+		}
+		var variables struct {
+			FilterOpts struct {
+				PerPage int `json:"per_page"`
+			} `json:"filterOpts"`
+		}
+		if err := json.Unmarshal(b, &variables); err != nil {
+			return err
+		}
+		count := f.scenes
+		if variables.FilterOpts.PerPage >= 0 && variables.FilterOpts.PerPage < count {
+			count = variables.FilterOpts.PerPage
+		}
+		scenes := make([]map[string]any, count)
+		for i := range scenes {
+			scenes[i] = map[string]any{"id": fmt.Sprint(i + 1)}
+		}
+		b, err = json.Marshal(map[string]any{"findScenes": map[string]any{"count": f.scenes, "scenes": scenes}})
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	default:
+		return fmt.Errorf("unexpected request %s", req.OpName)
+	}
+	return json.Unmarshal([]byte(data), resp.Data)
+}
+
+func TestSectionsByDefinitions_split(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sections.yml")
+	if err := os.WriteFile(path, []byte("sections:\n  - filter_id: 1\n    limit: 2\n    split: true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	definitions, err := definition.Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// as built by build, whose sections file is fixed by the configuration
+	ss, err := internal.SectionsByDefinitions(context.Background(), fakeStash{scenes: 5}, definitions)
+	if err != nil {
+		t.Fatalf("SectionsByDefinitions() error = %v", err)
+	}
+	ss = caps{}.apply(context.Background(), ss)
+	var got []string
+	for _, s := range ss {
+		got = append(got, fmt.Sprintf("%s:%d", s.Name, s.Len()))
+	}
+	want := []string{"POV (1/3):2", "POV (2/3):2", "POV (3/3):1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sections = %v, want %v", got, want)
+	}
+}
+
+func TestRefreshPlayHistory(t *testing.T) {
+	scenes := func(ids ...string) []gql.ScenePreviewParts {
+		parts := make([]gql.ScenePreviewParts, len(ids))
+		for i, id := range ids {
+			parts[i].Id = id
+		}
+		return parts
+	}
+	ss := []section.Section{
+		{Name: "Recently Played (1/2)", FilterId: "history:recently_played", PreviewPartsList: scenes("8", "9")},
+		{Name: "POV", FilterId: "1", PreviewPartsList: scenes("9")},
+		{Name: "Recently Played (2/2)", FilterId: "history:recently_played", PreviewPartsList: scenes("7")},
+	}
+
+	got, err := refreshPlayHistory(context.Background(), fakeStash{scenes: 5}, ss)
+	if err != nil {
+		t.Fatalf("refreshPlayHistory() error = %v", err)
+	}
+	var names []string
+	for _, s := range got {
+		var ids []string
+		for _, p := range s.PreviewPartsList {
+			ids = append(ids, p.Id)
+		}
+		names = append(names, fmt.Sprintf("%s:%v", s.Name, ids))
+	}
+	want := []string{"Recently Played (1/2):[1 2]", "POV:[9]", "Recently Played (2/2):[3]"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("sections = %v, want %v", names, want)
+	}
+}
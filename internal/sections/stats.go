@@ -11,10 +11,13 @@ func Count(sections []section.Section) Stats {
 	var linkCount int
 	sceneIds := make(map[string]any)
 	for _, s := range sections {
-		linkCount += len(s.PreviewPartsList)
+		linkCount += s.Len()
 		for _, p := range s.PreviewPartsList {
 			sceneIds[p.Id] = struct{}{}
 		}
+		for _, m := range s.Markers {
+			sceneIds[m.Scene.Id] = struct{}{}
+		}
 	}
 	return Stats{
 		Links:  linkCount,
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"stash-vr/internal/config"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	selfSignedValidity    = 825 * 24 * time.Hour
+	selfSignedRenewBefore = 30 * 24 * time.Hour
+)
+
+func loadCertificate(ctx context.Context) (tls.Certificate, error) {
+	if config.Get().TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.Get().TLSCertFile, config.Get().TLSKeyFile)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("LoadX509KeyPair: %w", err)
+		}
+		log.Ctx(ctx).Info().Str("cert", config.Get().TLSCertFile).Msg("Using configured TLS certificate")
+		return cert, nil
+	}
+	return loadOrCreateSelfSigned(ctx, filepath.Join(config.Get().DataDir, "tls"))
+}
+
+// loadOrCreateSelfSigned reuses the certificate persisted in dir as long as it's valid and covers all detected ips, otherwise a new one is generated.
+func loadOrCreateSelfSigned(ctx context.Context, dir string) (tls.Certificate, error) {
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	ips := detectIPs(ctx)
+
+	if cert, err := tls.LoadX509KeyPair(certPath, keyPath); err == nil {
+		if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil && isUsable(leaf, ips) {
+			log.Ctx(ctx).Info().Str("cert", certPath).Time("expires", leaf.NotAfter).Msg("Using self-signed TLS certificate")
+			return cert, nil
+		}
+		log.Ctx(ctx).Info().Str("cert", certPath).Msg("Self-signed TLS certificate expired or missing current ips, regenerating")
+	}
+
+	certPEM, keyPEM, err := generateSelfSigned(ips)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("generateSelfSigned: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return tls.Certificate{}, fmt.Errorf("create dir: %w", err)
+	}
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		return tls.Certificate{}, fmt.Errorf("write cert: %w", err)
+	}
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return tls.Certificate{}, fmt.Errorf("write key: %w", err)
+	}
+	log.Ctx(ctx).Info().Str("cert", certPath).Interface("ips", ips).Msg("Generated self-signed TLS certificate")
+
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+func isUsable(leaf *x509.Certificate, ips []net.IP) bool {
+	if time.Now().Add(selfSignedRenewBefore).After(leaf.NotAfter) {
+		return false
+	}
+	for _, ip := range ips {
+		if leaf.VerifyHostname(ip.String()) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func detectIPs(ctx context.Context) []net.IP {
+	ips := []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Failed to detect network addresses, certificate will only cover localhost")
+		return ips
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() || ipNet.IP.IsLinkLocalUnicast() || ipNet.IP.IsMulticast() {
+			continue
+		}
+		ips = append(ips, ipNet.IP)
+	}
+	return ips
+}
+
+func generateSelfSigned(ips []net.IP) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate serial: %w", err)
+	}
+
+	dnsNames := []string{"localhost"}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		dnsNames = append(dnsNames, hostname)
+	}
+
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "Stash-VR", Organization: []string{"Stash-VR self-signed"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              dnsNames,
+		IPAddresses:           ips,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create certificate: %w", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal key: %w", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return certPEM, keyPEM, nil
+}
@@ -2,31 +2,67 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"github.com/Khan/genqlient/graphql"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/sync/errgroup"
+	"net"
 	"net/http"
+	"stash-vr/internal/config"
 	"stash-vr/internal/router"
+	"strconv"
+	"strings"
 	"time"
 )
 
-func Listen(ctx context.Context, listenAddress string, client graphql.Client) error {
-	server := http.Server{
-		Addr:    listenAddress,
-		Handler: router.Build(client),
+func Listen(ctx context.Context, client graphql.Client) error {
+	var handler http.Handler = router.Build(client)
+
+	servers := []*http.Server{{
+		Addr:    listenAddress(config.Get().ListenPort),
+		Handler: handler,
+	}}
+
+	if config.Get().IsHTTPSEnabled {
+		cert, err := loadCertificate(ctx)
+		if err != nil {
+			return fmt.Errorf("certificate: %w", err)
+		}
+		servers[0].TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+
+		if config.Get().HTTPPort != 0 {
+			httpHandler := handler
+			if config.Get().IsHTTPRedirected {
+				httpHandler = redirectToHTTPS(config.Get().ListenPort)
+			}
+			servers = append(servers, &http.Server{
+				Addr:    listenAddress(config.Get().HTTPPort),
+				Handler: httpHandler,
+			})
+		}
 	}
 
 	g, gCtx := errgroup.WithContext(ctx)
 
-	g.Go(func() error {
-		log.Ctx(ctx).Info().Msg(fmt.Sprintf("Server listening at %s", listenAddress))
-		if err := server.ListenAndServe(); errors.Is(err, http.ErrServerClosed) {
-			return fmt.Errorf("listen: %w", err)
-		}
-		return nil
-	})
+	for _, server := range servers {
+		server := server
+		g.Go(func() error {
+			var err error
+			if server.TLSConfig != nil {
+				log.Ctx(ctx).Info().Msg(fmt.Sprintf("Server listening at https://%s", server.Addr))
+				err = server.ListenAndServeTLS("", "")
+			} else {
+				log.Ctx(ctx).Info().Msg(fmt.Sprintf("Server listening at http://%s", server.Addr))
+				err = server.ListenAndServe()
+			}
+			if !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("listen: %w", err)
+			}
+			return nil
+		})
+	}
 
 	g.Go(func() error {
 		<-gCtx.Done()
@@ -41,8 +77,10 @@ func Listen(ctx context.Context, listenAddress string, client graphql.Client) er
 			}
 		}()
 
-		if err := server.Shutdown(ctxShutdown); err != nil {
-			log.Ctx(ctx).Error().Err(err).Msg("Server shutdown error")
+		for _, server := range servers {
+			if err := server.Shutdown(ctxShutdown); err != nil {
+				log.Ctx(ctx).Error().Err(err).Str("addr", server.Addr).Msg("Server shutdown error")
+			}
 		}
 
 		return nil
@@ -55,3 +93,18 @@ func Listen(ctx context.Context, listenAddress string, client graphql.Client) er
 	log.Ctx(ctx).Debug().Msg("Server stopped without error")
 	return nil
 }
+
+func listenAddress(port int) string {
+	return net.JoinHostPort(config.Get().ListenAddress, strconv.Itoa(port))
+}
+
+func redirectToHTTPS(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = strings.Trim(r.Host, "[]")
+		}
+		target := "https://" + net.JoinHostPort(host, strconv.Itoa(httpsPort)) + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,141 @@
+// Package compat covers the differences between Stash versions: how saved filters are encoded and what the API
+// calls things. The Stash version is detected once and selects the Dialect used by filter decoders and queries.
+package compat
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"stash-vr/internal/stash/gql"
+	"strconv"
+	"sync"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/rs/zerolog/log"
+)
+
+// Version is a Stash release, e.g. v0.24.3.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// MinimumVersion is the oldest supported Stash, the queries of stash-vr request play counts (play_count) and increment
+// them (sceneIncrementPlayCount).
+var MinimumVersion = Version{0, 21, 0}
+
+var (
+	// v0_22 stores saved filters as objects (object_filter), older versions as a JSON string of JSON encoded criteria.
+	v0_22 = Version{0, 22, 0}
+	// v0_27 renamed movies to groups.
+	v0_27 = Version{0, 27, 0}
+
+	// defaultVersion is assumed until the version is detected, it's the version of the bundled schema.
+	defaultVersion = Version{0, 23, 0}
+)
+
+var rgxVersion = regexp.MustCompile(`^v?(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseVersion parses versions as reported by Stash, e.g. v0.24.3 or v0.24.3-45-gabcdef for development builds.
+func ParseVersion(s string) (Version, error) {
+	m := rgxVersion.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("unknown version format '%s'", s)
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		v.Patch, _ = strconv.Atoi(m[3])
+	}
+	return v, nil
+}
+
+func (v Version) AtLeast(o Version) bool {
+	if v.Major != o.Major {
+		return v.Major > o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor > o.Minor
+	}
+	return v.Patch >= o.Patch
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Dialect is what differs between Stash versions for stash-vr.
+type Dialect struct {
+	Version Version
+	// HasObjectFilter returns saved filters as objects, older versions as a JSON string.
+	HasObjectFilter bool
+	// HasGroups names movies groups, in saved filters, filter modes and sorts.
+	HasGroups bool
+}
+
+func DialectOf(v Version) Dialect {
+	return Dialect{
+		Version:         v,
+		HasObjectFilter: v.AtLeast(v0_22),
+		HasGroups:       v.AtLeast(v0_27),
+	}
+}
+
+// FilterModeGroups is the filter mode of movies since they're named groups, it's not in the bundled schema.
+const FilterModeGroups gql.FilterMode = "GROUPS"
+
+// IsMovieMode reports whether mode is of movies, named groups by newer versions.
+func IsMovieMode(mode gql.FilterMode) bool {
+	return mode == gql.FilterModeMovies || mode == FilterModeGroups
+}
+
+// MovieSceneSort orders the scenes of a movie by their scene number in it.
+func (d Dialect) MovieSceneSort() string {
+	if d.HasGroups {
+		return "group_scene_number"
+	}
+	return "movie_scene_number"
+}
+
+var (
+	mu       sync.RWMutex
+	detected *Dialect
+)
+
+// Detect queries the version of Stash and selects its dialect.
+func Detect(ctx context.Context, client graphql.Client) (Dialect, error) {
+	response, err := gql.Version(ctx, client)
+	if err != nil {
+		return Current(), fmt.Errorf("Version: %w", err)
+	}
+	v, err := ParseVersion(response.Version.Version)
+	if err != nil {
+		return Current(), err
+	}
+	d := DialectOf(v)
+
+	mu.Lock()
+	detected = &d
+	mu.Unlock()
+	log.Ctx(ctx).Debug().Str("version", v.String()).Interface("dialect", d).Msg("Stash dialect selected")
+	return d, nil
+}
+
+// IsDetected reports whether the version of Stash has been detected.
+func IsDetected() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return detected != nil
+}
+
+// Current returns the dialect of the detected Stash version, or that of the bundled schema if not yet detected.
+func Current() Dialect {
+	mu.RLock()
+	defer mu.RUnlock()
+	if detected == nil {
+		return DialectOf(defaultVersion)
+	}
+	return *detected
+}
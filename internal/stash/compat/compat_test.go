@@ -0,0 +1,42 @@
+package compat
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Version
+		wantErr bool
+	}{
+		{"v0.24.3", Version{0, 24, 3}, false},
+		{"v0.27.0-45-gabcdef", Version{0, 27, 0}, false},
+		{"0.18", Version{0, 18, 0}, false},
+		{"dev", Version{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseVersion(tt.s)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseVersion() = %v, %v, want %v, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDialectOf(t *testing.T) {
+	tests := []struct {
+		v    Version
+		want Dialect
+	}{
+		{Version{0, 21, 0}, Dialect{Version: Version{0, 21, 0}}},
+		{Version{0, 24, 3}, Dialect{Version: Version{0, 24, 3}, HasObjectFilter: true}},
+		{Version{1, 0, 0}, Dialect{Version: Version{1, 0, 0}, HasObjectFilter: true, HasGroups: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.v.String(), func(t *testing.T) {
+			if got := DialectOf(tt.v); got != tt.want {
+				t.Errorf("DialectOf() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
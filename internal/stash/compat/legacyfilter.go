@@ -0,0 +1,68 @@
+package compat
+
+import (
+	"encoding/json"
+	"fmt"
+	"stash-vr/internal/stash/gql"
+	"strings"
+)
+
+// legacyFilter is a saved filter as stored by Stash before v0.22, e.g.
+// {"sortby":"date","sortdir":"desc","q":"","c":["{\"type\":\"tags\",\"modifier\":\"INCLUDES\",\"value\":...}"]}.
+type legacyFilter struct {
+	SortBy   string            `json:"sortby"`
+	SortDir  string            `json:"sortdir"`
+	Q        string            `json:"q"`
+	Criteria []json.RawMessage `json:"c"`
+}
+
+type legacyCriterion struct {
+	Type     string `json:"type"`
+	Modifier string `json:"modifier"`
+	Value    any    `json:"value"`
+}
+
+// DecodeLegacyFilter decodes the filter string of a saved filter of Stash before v0.22 into the find filter and
+// object filter of newer versions.
+func DecodeLegacyFilter(filter string) (*gql.SavedFilterPartsFind_filterSavedFindFilterType, map[string]any, error) {
+	var f legacyFilter
+	if err := json.Unmarshal([]byte(filter), &f); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal filter: %w", err)
+	}
+
+	findFilter := &gql.SavedFilterPartsFind_filterSavedFindFilterType{
+		Q:         f.Q,
+		Per_page:  -1,
+		Sort:      f.SortBy,
+		Direction: gql.SortDirectionEnum(strings.ToUpper(f.SortDir)),
+	}
+	if findFilter.Direction == "" {
+		findFilter.Direction = gql.SortDirectionEnumAsc
+	}
+
+	objectFilter := make(map[string]any, len(f.Criteria))
+	for _, raw := range f.Criteria {
+		c, err := decodeLegacyCriterion(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		objectFilter[c.Type] = map[string]any{"modifier": c.Modifier, "value": c.Value}
+	}
+	return findFilter, objectFilter, nil
+}
+
+// decodeLegacyCriterion decodes a criterion, it's a JSON encoded string in most versions and an object in some.
+func decodeLegacyCriterion(raw json.RawMessage) (legacyCriterion, error) {
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err == nil {
+		raw = json.RawMessage(encoded)
+	}
+	var c legacyCriterion
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return legacyCriterion{}, fmt.Errorf("unmarshal criterion '%s': %w", raw, err)
+	}
+	if c.Type == "" {
+		return legacyCriterion{}, fmt.Errorf("criterion without type '%s'", raw)
+	}
+	return c, nil
+}
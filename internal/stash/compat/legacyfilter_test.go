@@ -0,0 +1,29 @@
+package compat
+
+import (
+	"stash-vr/internal/stash/gql"
+	"testing"
+)
+
+func TestDecodeLegacyFilter(t *testing.T) {
+	filter := `{"sortby":"date","sortdir":"desc","q":"beach","currentPage":1,"c":[` +
+		`"{\"type\":\"tags\",\"modifier\":\"INCLUDES\",\"value\":{\"items\":[{\"id\":\"1\",\"label\":\"POV\"}],\"depth\":0}}",` +
+		`{"type":"rating","modifier":"GREATER_THAN","value":{"value":3}}]}`
+
+	findFilter, objectFilter, err := DecodeLegacyFilter(filter)
+	if err != nil {
+		t.Fatalf("DecodeLegacyFilter() error = %v", err)
+	}
+	if findFilter.Q != "beach" || findFilter.Sort != "date" || findFilter.Direction != gql.SortDirectionEnumDesc {
+		t.Errorf("findFilter = %+v, want q beach sorted by date desc", findFilter)
+	}
+	if len(objectFilter) != 2 || objectFilter["tags"] == nil || objectFilter["rating"] == nil {
+		t.Errorf("objectFilter = %+v, want criteria tags and rating", objectFilter)
+	}
+	if findFilter, _, err := DecodeLegacyFilter(`{"sortby":"title","c":[]}`); err != nil || findFilter.Direction != gql.SortDirectionEnumAsc {
+		t.Errorf("DecodeLegacyFilter() without sortdir = %+v, %v, want ascending", findFilter, err)
+	}
+	if _, _, err := DecodeLegacyFilter(`{"c":["{\"modifier\":\"EQUALS\"}"]}`); err == nil {
+		t.Error("DecodeLegacyFilter() error = nil, want error for a criterion without type")
+	}
+}
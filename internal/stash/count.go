@@ -0,0 +1,33 @@
+package stash
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// countScenesByRawFilter counts scenes by a scene filter that isn't typed by the bundled schema, e.g. with criteria
+// unknown to stash-vr.
+const countScenesByRawFilter = `query CountScenesByRawFilter($scene_filter: SceneFilterType, $q: String) {
+    findScenes(scene_filter: $scene_filter, filter: {q: $q, per_page: 1}) { count }
+}`
+
+// CountScenesByRawFilter returns the number of scenes Stash counts for sceneFilter and the search term q, sceneFilter
+// is sent as is, see filter.RawSceneFilter.
+func CountScenesByRawFilter(ctx context.Context, client graphql.Client, sceneFilter map[string]any, q string) (int, error) {
+	req := &graphql.Request{
+		OpName:    "CountScenesByRawFilter",
+		Query:     countScenesByRawFilter,
+		Variables: map[string]any{"scene_filter": sceneFilter, "q": q},
+	}
+	var data struct {
+		FindScenes struct {
+			Count int `json:"count"`
+		} `json:"findScenes"`
+	}
+	if err := client.MakeRequest(ctx, req, &graphql.Response{Data: &data}); err != nil {
+		return 0, fmt.Errorf("CountScenesByRawFilter: %w", err)
+	}
+	return data.FindScenes.Count, nil
+}
@@ -3,8 +3,10 @@ package stash
 import (
 	"context"
 	"fmt"
+	"stash-vr/internal/stash/compat"
 	"stash-vr/internal/stash/gql"
 	"strconv"
+	"strings"
 
 	"github.com/Khan/genqlient/graphql"
 	"github.com/rs/zerolog/log"
@@ -12,20 +14,24 @@ import (
 
 func FindFiltersByName(ctx context.Context, client graphql.Client, filterNames []string) []gql.SavedFilterParts {
 	filters := make([]gql.SavedFilterParts, 0, len(filterNames))
-	response, _ := gql.FindSavedSceneFilters(ctx, client)
+	savedFilters, err := findAllSavedFilters(ctx, client)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(fmt.Errorf("FindFiltersByName: %w", err)).Strs("filterNames", filterNames).Msg("Skipped filters")
+		return filters
+	}
 
 	for _, filterName := range filterNames {
 		found := false
-		for _, filter := range response.FindSavedFilters {
+		for _, filter := range savedFilters {
 			if filter.Name == filterName {
-				filters = append(filters, filter.SavedFilterParts)
+				filters = append(filters, filter)
 				found = true
 				break
 			}
 		}
 
 		if !found {
-			log.Ctx(ctx).Warn().Err(fmt.Errorf("FindFiltersById: FindSavedFilter: Filter not found")).Str("filterName", filterName).Msg("Skipped filter")
+			log.Ctx(ctx).Warn().Err(fmt.Errorf("FindFiltersByName: Filter not found")).Str("filterName", filterName).Msg("Skipped filter")
 			continue
 		}
 	}
@@ -37,50 +43,169 @@ func FindFiltersById(ctx context.Context, client graphql.Client, filterIds []str
 	filters := make([]gql.SavedFilterParts, 0, len(filterIds))
 
 	for _, filterId := range filterIds {
-		savedFilterResponse, err := gql.FindSavedFilter(ctx, client, filterId)
+		savedFilter, err := findSavedFilter(ctx, client, filterId)
 		if err != nil {
-			log.Ctx(ctx).Warn().Err(fmt.Errorf("FindFiltersById: FindSavedFilter: %w", err)).Str("filterId", filterId).Msg("Skipped filter")
+			log.Ctx(ctx).Warn().Err(fmt.Errorf("FindFiltersById: %w", err)).Str("filterId", filterId).Msg("Skipped filter")
 			continue
 		}
-		if savedFilterResponse.FindSavedFilter == nil {
+		if savedFilter == nil {
 			log.Ctx(ctx).Warn().Err(fmt.Errorf("FindFiltersById: FindSavedFilter: Filter not found")).Str("filterId", filterId).Msg("Skipped filter")
 			continue
 		}
-		filters = append(filters, savedFilterResponse.FindSavedFilter.SavedFilterParts)
+		filters = append(filters, *savedFilter)
 	}
 
 	return filters
 }
 
-func FindSavedFilterIdsByFrontPage(ctx context.Context, client graphql.Client) ([]string, error) {
+// FindSavedFilterByName returns the saved filter named name, nil if there is none.
+func FindSavedFilterByName(ctx context.Context, client graphql.Client, name string) (*gql.SavedFilterParts, error) {
+	savedFilters, err := findAllSavedFilters(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("FindSavedFilterByName: %w", err)
+	}
+	for _, filter := range savedFilters {
+		if filter.Name == name {
+			return &filter, nil
+		}
+	}
+	return nil, nil
+}
+
+// FindSavedSceneFilters returns the saved filters of scenes, in the encoding of the detected Stash version.
+func FindSavedSceneFilters(ctx context.Context, client graphql.Client) ([]gql.SavedFilterParts, error) {
+	if !compat.Current().HasObjectFilter {
+		return findSavedFiltersLegacy(ctx, client, []gql.FilterMode{gql.FilterModeScenes})
+	}
+	response, err := gql.FindSavedSceneFilters(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("FindSavedSceneFilters: %w", err)
+	}
+	filters := make([]gql.SavedFilterParts, len(response.FindSavedFilters))
+	for i, f := range response.FindSavedFilters {
+		filters[i] = f.SavedFilterParts
+	}
+	return filters, nil
+}
+
+func findAllSavedFilters(ctx context.Context, client graphql.Client) ([]gql.SavedFilterParts, error) {
+	if !compat.Current().HasObjectFilter {
+		return findSavedFiltersLegacy(ctx, client, legacyFilterModes)
+	}
+	response, err := gql.FindSavedFilters(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("FindSavedFilters: %w", err)
+	}
+	filters := make([]gql.SavedFilterParts, len(response.FindSavedFilters))
+	for i, f := range response.FindSavedFilters {
+		filters[i] = f.SavedFilterParts
+	}
+	return filters, nil
+}
+
+func findSavedFilter(ctx context.Context, client graphql.Client, id string) (*gql.SavedFilterParts, error) {
+	if !compat.Current().HasObjectFilter {
+		return findSavedFilterLegacy(ctx, client, id)
+	}
+	response, err := gql.FindSavedFilter(ctx, client, id)
+	if err != nil {
+		return nil, fmt.Errorf("FindSavedFilter: %w", err)
+	}
+	if response.FindSavedFilter == nil {
+		return nil, nil
+	}
+	return &response.FindSavedFilter.SavedFilterParts, nil
+}
+
+// frontPageCustomFilterLimit is the number of items Stash shows in a premade front page row.
+const frontPageCustomFilterLimit = 25
+
+// FrontPageItem is a row of the Stash front page, either a saved filter or one of Stash's premade (custom) filters.
+type FrontPageItem struct {
+	SavedFilterId string
+	Custom        *FrontPageCustomFilter
+}
+
+type FrontPageCustomFilter struct {
+	Name      string
+	Mode      gql.FilterMode
+	SortBy    string
+	Direction gql.SortDirectionEnum
+	Limit     int
+}
+
+func FindFrontPageItems(ctx context.Context, client graphql.Client) ([]FrontPageItem, error) {
 	configurationResponse, err := gql.UIConfiguration(ctx, client)
 	if err != nil {
 		return nil, fmt.Errorf("UIConfiguration: %w", err)
 	}
 
-	frontPageContent := configurationResponse.Configuration.Ui["frontPageContent"]
-	if frontPageContent == nil {
+	frontPageContent, ok := configurationResponse.Configuration.Ui["frontPageContent"].([]interface{})
+	if !ok {
 		log.Ctx(ctx).Info().Msg("No frontpage content found")
 		return nil, nil
 	}
 
-	frontPageFilters := configurationResponse.Configuration.Ui["frontPageContent"].([]interface{})
-	filterIds := make([]string, 0, len(frontPageFilters))
-	for _, _filter := range frontPageFilters {
-		filter := _filter.(map[string]interface{})
-		typeName := filter["__typename"].(string)
-		if typeName == "CustomFilter" {
-			log.Ctx(ctx).Debug().Msg("Filter skipped: Predefined filter on front page: Only user created saved scene filters are supported.")
+	items := make([]FrontPageItem, 0, len(frontPageContent))
+	for _, _filter := range frontPageContent {
+		filter, ok := _filter.(map[string]interface{})
+		if !ok {
 			continue
 		}
-		if typeName != "SavedFilter" {
-			log.Ctx(ctx).Debug().Str("type", typeName).Msg("Filter skipped: Filter of unsupported type on front page: Only user created saved scene filters are supported")
-			continue
+		typeName, _ := filter["__typename"].(string)
+		switch typeName {
+		case "SavedFilter":
+			savedFilterId, ok := filter["savedFilterId"].(float64)
+			if !ok {
+				log.Ctx(ctx).Debug().Interface("filter", filter).Msg("Filter skipped: Saved filter on front page without id")
+				continue
+			}
+			items = append(items, FrontPageItem{SavedFilterId: strconv.Itoa(int(savedFilterId))})
+		case "CustomFilter":
+			items = append(items, FrontPageItem{Custom: parseFrontPageCustomFilter(filter)})
+		default:
+			log.Ctx(ctx).Debug().Str("type", typeName).Msg("Filter skipped: Filter of unsupported type on front page")
 		}
+	}
+
+	return items, nil
+}
 
-		filterId := strconv.Itoa(int(filter["savedFilterId"].(float64)))
-		filterIds = append(filterIds, filterId)
+func parseFrontPageCustomFilter(filter map[string]interface{}) *FrontPageCustomFilter {
+	f := FrontPageCustomFilter{
+		Limit: frontPageCustomFilterLimit,
+	}
+	mode, _ := filter["mode"].(string)
+	f.Mode = gql.FilterMode(mode)
+	f.SortBy, _ = filter["sortBy"].(string)
+	direction, _ := filter["direction"].(string)
+	f.Direction = gql.SortDirectionEnum(strings.ToUpper(direction))
+	if limit, ok := filter["limit"].(float64); ok && limit > 0 {
+		f.Limit = int(limit)
 	}
 
-	return filterIds, nil
+	var messageId, objects string
+	if message, ok := filter["message"].(map[string]interface{}); ok {
+		messageId, _ = message["id"].(string)
+		if values, ok := message["values"].(map[string]interface{}); ok {
+			objects, _ = values["objects"].(string)
+		}
+	}
+	f.Name = customFilterName(messageId, objects)
+	return &f
+}
+
+// customFilterName translates the message id Stash uses to title a premade filter, e.g. recently_released_objects with
+// objects "scenes" becomes "Recently Released Scenes".
+func customFilterName(messageId string, objects string) string {
+	words := strings.Split(messageId, "_")
+	for i, w := range words {
+		if w == "objects" {
+			w = objects
+		}
+		if w != "" {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.TrimSpace(strings.Join(words, " "))
 }
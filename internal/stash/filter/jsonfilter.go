@@ -1,6 +1,7 @@
 package filter
 
 import (
+	"errors"
 	"fmt"
 	"stash-vr/internal/stash/gql"
 	"strconv"
@@ -10,6 +11,34 @@ type jsonCriterion struct {
 	Modifier string `json:"modifier"`
 	Value    any    `json:"value"`
 }
+
+// errUnsupportedCriterion is returned for criteria that aren't translated, they're left out of the filter.
+var errUnsupportedCriterion = errors.New("unsupported criterion")
+
+// Sub-filter operators of a saved filter, their value is a nested set of criteria.
+const (
+	operatorAnd = "AND"
+	operatorOr  = "OR"
+	operatorNot = "NOT"
+)
+
+func isSubFilterOperator(name string) bool {
+	return name == operatorAnd || name == operatorOr || name == operatorNot
+}
+
+// newJsonCriterion reads a criterion of a saved filter, e.g. {"modifier": "INCLUDES", "value": ...}.
+func newJsonCriterion(raw any) (jsonCriterion, error) {
+	m, ok := raw.(stringAnyMap)
+	if !ok {
+		return jsonCriterion{}, newUnexpectedTypeErr(raw)
+	}
+	modifier, err := getValue[string](m, "modifier")
+	if err != nil {
+		return jsonCriterion{}, err
+	}
+	return jsonCriterion{Modifier: modifier, Value: m["value"]}, nil
+}
+
 type errUnexpectedType struct {
 	source any
 }
@@ -24,71 +53,72 @@ func newUnexpectedTypeErr(source any) *errUnexpectedType {
 	return &errUnexpectedType{source}
 }
 
+// asHierarchicalMultiCriterionInput accepts {"items": [...], "excluded": [...], "depth": n} as well as a plain list of
+// items, items are ids or {"id": id, "label": name}.
 func (c jsonCriterion) asHierarchicalMultiCriterionInput() (*gql.HierarchicalMultiCriterionInput, error) {
-	if c.Value == nil {
-		return &gql.HierarchicalMultiCriterionInput{
-			Modifier: gql.CriterionModifier(c.Modifier),
-		}, nil
-	}
-	m, ok := c.Value.(stringAnyMap)
-	if !ok {
+	input := &gql.HierarchicalMultiCriterionInput{Modifier: gql.CriterionModifier(c.Modifier)}
+	var err error
+	switch v := c.Value.(type) {
+	case nil:
+	case []any:
+		input.Value, err = asIds(v)
+	case stringAnyMap:
+		input.Value, input.Excludes, err = asItemsAndExcluded(v)
+		if err != nil {
+			return nil, err
+		}
+		var depth float64
+		depth, err = getValue[float64](v, "depth")
+		input.Depth = int(depth)
+	default:
 		return nil, newUnexpectedTypeErr(c.Value)
 	}
+	if err != nil {
+		return nil, err
+	}
+	if input.Excludes == nil && c.Value != nil {
+		input.Excludes = make([]string, 0)
+	}
+	return input, nil
+}
 
+func asItemsAndExcluded(m stringAnyMap) ([]string, []string, error) {
 	items, err := getValue[[]any](m, "items")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	ids, err := asIds(items)
+	if err != nil {
+		return nil, nil, err
+	}
+	excluded, err := getValue[[]any](m, "excluded")
+	if err != nil {
+		return nil, nil, err
+	}
+	excludedIds, err := asIds(excluded)
+	if err != nil {
+		return nil, nil, err
 	}
+	return ids, excludedIds, nil
+}
 
+// asIds reads a list of ids, as strings, numbers or {"id": id, "label": name}.
+func asIds(items []any) ([]string, error) {
 	ids := make([]string, len(items))
 	for i, item := range items {
-		mid, ok := item.(stringAnyMap)
-		if !ok {
-			return nil, newUnexpectedTypeErr(item)
-		}
-		id_float, ok := mid["id"].(float64)
-		if ok {
-			ids[i] = fmt.Sprintf("%v", int(id_float+0.5))
-			continue
+		if mid, ok := item.(stringAnyMap); ok {
+			item = mid["id"]
 		}
-		id_string, ok := mid["id"].(string)
-		if !ok {
-			return nil, newUnexpectedTypeErr(mid["id"])
-		}
-
-		ids[i] = id_string
-	}
-
-	excluded, err := getValue[[]any](m, "excluded")
-	var excluded_ids []string
-	if err == nil {
-		excluded_ids = make([]string, len(excluded))
-		for i, item := range excluded {
-			mid, ok := item.(stringAnyMap)
-			if !ok {
-				return nil, newUnexpectedTypeErr(item)
-			}
-			id_float, ok := mid["id"].(float64)
-			if ok {
-				excluded_ids[i] = fmt.Sprintf("%v", int(id_float+0.5))
-				continue
-			}
-			id_string, ok := mid["id"].(string)
-			if !ok {
-				return nil, newUnexpectedTypeErr(mid["id"])
-			}
-
-			excluded_ids[i] = id_string
+		switch id := item.(type) {
+		case string:
+			ids[i] = id
+		case float64:
+			ids[i] = fmt.Sprintf("%v", int(id+0.5))
+		default:
+			return nil, newUnexpectedTypeErr(item)
 		}
-	} else {
-		excluded_ids = make([]string, 0)
 	}
-
-	return &gql.HierarchicalMultiCriterionInput{
-		Value:    ids,
-		Modifier: gql.CriterionModifier(c.Modifier),
-		Excludes: excluded_ids,
-	}, nil
+	return ids, nil
 }
 
 func (c jsonCriterion) asStringCriterionInput() (*gql.StringCriterionInput, error) {
@@ -107,35 +137,48 @@ func (c jsonCriterion) asStringCriterionInput() (*gql.StringCriterionInput, erro
 	}, nil
 }
 
+// asIntCriterionInput accepts {"value": n, "value2": n} as well as a plain number.
 func (c jsonCriterion) asIntCriterionInput() (*gql.IntCriterionInput, error) {
-	if c.Value == nil {
-		return &gql.IntCriterionInput{
-			Modifier: gql.CriterionModifier(c.Modifier),
-		}, nil
-	}
-	m, ok := c.Value.(stringAnyMap)
-	if !ok {
+	input := &gql.IntCriterionInput{Modifier: gql.CriterionModifier(c.Modifier)}
+	switch v := c.Value.(type) {
+	case nil:
+	case float64:
+		input.Value = int(v)
+	case stringAnyMap:
+		value, err := getValue[float64](v, "value")
+		if err != nil {
+			return nil, err
+		}
+		value2, err := getValue[float64](v, "value2")
+		if err != nil {
+			return nil, err
+		}
+		input.Value, input.Value2 = int(value), int(value2)
+	default:
 		return nil, newUnexpectedTypeErr(c.Value)
 	}
+	return input, nil
+}
 
-	value, err := getValue[float64](m, "value")
+// setRatingCriterion sets a rating criterion as rating100, 1-100. A rating criterion is 1-5 as saved by Stash before
+// v0.18.
+func (c jsonCriterion) setRatingCriterion(name string, rating100 **gql.IntCriterionInput) error {
+	input, err := c.asIntCriterionInput()
 	if err != nil {
-		return nil, newUnexpectedTypeErr(m["value"])
+		return err
 	}
-
-	value2, err := getValue[float64](m, "value2")
-	if err != nil {
-		return nil, newUnexpectedTypeErr(m["value2"])
+	if name == "rating" {
+		input.Value, input.Value2 = input.Value*20, input.Value2*20
 	}
-
-	return &gql.IntCriterionInput{
-		Value:    int(value),
-		Value2:   int(value2),
-		Modifier: gql.CriterionModifier(c.Modifier),
-	}, nil
+	*rating100 = input
+	return nil
 }
 
+// asBool accepts a bool as well as "true" or "false".
 func (c jsonCriterion) asBool() (bool, error) {
+	if b, ok := c.Value.(bool); ok {
+		return b, nil
+	}
 	value, ok := c.Value.(string)
 	if !ok {
 		return false, newUnexpectedTypeErr(c.Value)
@@ -147,6 +190,37 @@ func (c jsonCriterion) asBool() (bool, error) {
 	return b, nil
 }
 
+func (c jsonCriterion) asBoolPointer() (*bool, error) {
+	b, err := c.asBool()
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// asPhashDistanceCriterionInput accepts {"value": phash, "distance": n} as well as a plain phash with distance 0.
+func (c jsonCriterion) asPhashDistanceCriterionInput() (*gql.PhashDistanceCriterionInput, error) {
+	input := &gql.PhashDistanceCriterionInput{Modifier: gql.CriterionModifier(c.Modifier)}
+	switch v := c.Value.(type) {
+	case nil:
+	case string:
+		input.Value = v
+	case stringAnyMap:
+		value, err := getValue[string](v, "value")
+		if err != nil {
+			return nil, err
+		}
+		distance, err := getValue[float64](v, "distance")
+		if err != nil {
+			return nil, err
+		}
+		input.Value, input.Distance = value, int(distance)
+	default:
+		return nil, newUnexpectedTypeErr(c.Value)
+	}
+	return input, nil
+}
+
 func (c jsonCriterion) asPHashDuplicationCriterionInput() (*gql.PHashDuplicationCriterionInput, error) {
 	b, err := c.asBool()
 	if err != nil {
@@ -213,53 +287,53 @@ func (c jsonCriterion) asString() (string, error) {
 	return s, nil
 }
 
+// asMultiCriterionInput accepts a list of items as well as {"items": [...], "excluded": [...]}, items are ids or
+// {"id": id, "label": name}.
 func (c jsonCriterion) asMultiCriterionInput() (*gql.MultiCriterionInput, error) {
-	if c.Value == nil {
-		return &gql.MultiCriterionInput{
-			Modifier: gql.CriterionModifier(c.Modifier),
-		}, nil
-	}
-
-	value, ok := c.Value.([]any)
-	if !ok {
+	input := &gql.MultiCriterionInput{Modifier: gql.CriterionModifier(c.Modifier)}
+	var err error
+	switch v := c.Value.(type) {
+	case nil:
+	case []any:
+		input.Value, err = asIds(v)
+	case stringAnyMap:
+		input.Value, input.Excludes, err = asItemsAndExcluded(v)
+	default:
 		return nil, newUnexpectedTypeErr(c.Value)
 	}
-	ss := make([]string, len(value))
-	for i, v := range value {
-		s, ok := v.(string)
-		if !ok {
-			return nil, newUnexpectedTypeErr(v)
-		}
-		ss[i] = s
+	if err != nil {
+		return nil, err
 	}
-	return &gql.MultiCriterionInput{
-		Value:    ss,
-		Modifier: gql.CriterionModifier(c.Modifier),
-	}, nil
+	return input, nil
 }
 
-func (c jsonCriterion) asTimestampCriterionInput() (*gql.TimestampCriterionInput, error) {
-	if c.Value == nil {
-		return &gql.TimestampCriterionInput{
-			Modifier: gql.CriterionModifier(c.Modifier),
-		}, nil
-	}
-
-	m, ok := c.Value.(stringAnyMap)
-	if !ok {
-		return nil, newUnexpectedTypeErr(c.Value)
-	}
-
-	value, err := getValue[string](m, "value")
-	if err != nil {
-		return nil, err
+// asValues reads {"value": ..., "value2": ...} as well as a plain value of dates and timestamps.
+func (c jsonCriterion) asValues() (string, string, error) {
+	switch v := c.Value.(type) {
+	case nil:
+		return "", "", nil
+	case string:
+		return v, "", nil
+	case stringAnyMap:
+		value, err := getValue[string](v, "value")
+		if err != nil {
+			return "", "", err
+		}
+		value2, err := getValue[string](v, "value2")
+		if err != nil {
+			return "", "", err
+		}
+		return value, value2, nil
+	default:
+		return "", "", newUnexpectedTypeErr(c.Value)
 	}
+}
 
-	value2, err := getValue[string](m, "value2")
+func (c jsonCriterion) asTimestampCriterionInput() (*gql.TimestampCriterionInput, error) {
+	value, value2, err := c.asValues()
 	if err != nil {
 		return nil, err
 	}
-
 	return &gql.TimestampCriterionInput{
 		Value:    value,
 		Value2:   value2,
@@ -268,27 +342,10 @@ func (c jsonCriterion) asTimestampCriterionInput() (*gql.TimestampCriterionInput
 }
 
 func (c jsonCriterion) asDateCriterionInput() (*gql.DateCriterionInput, error) {
-	if c.Value == nil {
-		return &gql.DateCriterionInput{
-			Modifier: gql.CriterionModifier(c.Modifier),
-		}, nil
-	}
-
-	m, ok := c.Value.(stringAnyMap)
-	if !ok {
-		return nil, newUnexpectedTypeErr(c.Value)
-	}
-
-	value, err := getValue[string](m, "value")
+	value, value2, err := c.asValues()
 	if err != nil {
 		return nil, err
 	}
-
-	value2, err := getValue[string](m, "value2")
-	if err != nil {
-		return nil, err
-	}
-
 	return &gql.DateCriterionInput{
 		Value:    value,
 		Value2:   value2,
@@ -0,0 +1,396 @@
+package filter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stash-vr/internal/stash/compat"
+	"stash-vr/internal/stash/gql"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// PerformerFilter, StudioFilter and MovieFilter are parsed saved filters of the respective modes.
+// They select the objects of which each becomes a section of its scenes. Unsupported lists the criteria left out.
+type PerformerFilter struct {
+	FilterOpts      gql.FindFilterType
+	PerformerFilter gql.PerformerFilterType
+	Unsupported     []string
+}
+
+type StudioFilter struct {
+	FilterOpts   gql.FindFilterType
+	StudioFilter gql.StudioFilterType
+	Unsupported  []string
+}
+
+type MovieFilter struct {
+	FilterOpts  gql.FindFilterType
+	MovieFilter gql.MovieFilterType
+	Unsupported []string
+}
+
+// SceneMarkerFilter is a parsed saved filter of mode SCENE_MARKERS, it selects the markers of a marker section.
+type SceneMarkerFilter struct {
+	FilterOpts        gql.FindFilterType
+	SceneMarkerFilter gql.SceneMarkerFilterType
+	Unsupported       []string
+}
+
+// objectFilterOpts returns all objects in the order of the saved filter.
+func objectFilterOpts(savedFilter gql.SavedFilterParts) gql.FindFilterType {
+	return gql.FindFilterType{
+		Per_page:  -1,
+		Sort:      savedFilter.Find_filter.Sort,
+		Direction: DirectionOrDefault(savedFilter.Find_filter.Sort, string(savedFilter.Find_filter.Direction)),
+	}
+}
+
+func SavedFilterToPerformerFilter(ctx context.Context, savedFilter gql.SavedFilterParts) (PerformerFilter, error) {
+	if savedFilter.Mode != gql.FilterModePerformers {
+		return PerformerFilter{}, fmt.Errorf("unsupported filter mode")
+	}
+	f := PerformerFilter{FilterOpts: objectFilterOpts(savedFilter)}
+	for name, raw := range savedFilter.Object_filter {
+		err := setPerformerFilterCriterion(ctx, name, raw, &f.PerformerFilter)
+		if errors.Is(err, errUnsupportedCriterion) {
+			f.Unsupported = append(f.Unsupported, name)
+			continue
+		}
+		if err != nil {
+			return PerformerFilter{}, fmt.Errorf("setPerformerFilterCriterion: %w", err)
+		}
+	}
+	return f, nil
+}
+
+func SavedFilterToStudioFilter(ctx context.Context, savedFilter gql.SavedFilterParts) (StudioFilter, error) {
+	if savedFilter.Mode != gql.FilterModeStudios {
+		return StudioFilter{}, fmt.Errorf("unsupported filter mode")
+	}
+	f := StudioFilter{FilterOpts: objectFilterOpts(savedFilter)}
+	for name, raw := range savedFilter.Object_filter {
+		err := setStudioFilterCriterion(ctx, name, raw, &f.StudioFilter)
+		if errors.Is(err, errUnsupportedCriterion) {
+			f.Unsupported = append(f.Unsupported, name)
+			continue
+		}
+		if err != nil {
+			return StudioFilter{}, fmt.Errorf("setStudioFilterCriterion: %w", err)
+		}
+	}
+	return f, nil
+}
+
+func SavedFilterToMovieFilter(ctx context.Context, savedFilter gql.SavedFilterParts) (MovieFilter, error) {
+	if !compat.IsMovieMode(savedFilter.Mode) {
+		return MovieFilter{}, fmt.Errorf("unsupported filter mode")
+	}
+	f := MovieFilter{FilterOpts: objectFilterOpts(savedFilter)}
+	for name, raw := range savedFilter.Object_filter {
+		err := setMovieFilterCriterion(ctx, name, raw, &f.MovieFilter)
+		if errors.Is(err, errUnsupportedCriterion) {
+			f.Unsupported = append(f.Unsupported, name)
+			continue
+		}
+		if err != nil {
+			return MovieFilter{}, fmt.Errorf("setMovieFilterCriterion: %w", err)
+		}
+	}
+	return f, nil
+}
+
+func SavedFilterToSceneMarkerFilter(ctx context.Context, savedFilter gql.SavedFilterParts) (SceneMarkerFilter, error) {
+	if savedFilter.Mode != gql.FilterModeSceneMarkers {
+		return SceneMarkerFilter{}, fmt.Errorf("unsupported filter mode")
+	}
+	f := SceneMarkerFilter{FilterOpts: objectFilterOpts(savedFilter)}
+	for name, raw := range savedFilter.Object_filter {
+		err := setSceneMarkerFilterCriterion(ctx, name, raw, &f.SceneMarkerFilter)
+		if errors.Is(err, errUnsupportedCriterion) {
+			f.Unsupported = append(f.Unsupported, name)
+			continue
+		}
+		if err != nil {
+			return SceneMarkerFilter{}, fmt.Errorf("setSceneMarkerFilterCriterion: %w", err)
+		}
+	}
+	return f, nil
+}
+
+func setPerformerFilterCriterion(ctx context.Context, name string, criterionRaw any, performerFilter *gql.PerformerFilterType) error {
+	criterion, err := newJsonCriterion(criterionRaw)
+	if err != nil {
+		return fmt.Errorf("criterion '%s': %w", name, err)
+	}
+	switch name {
+	//HierarchicalMultiCriterionInput
+	case "tags":
+		performerFilter.Tags, err = criterion.asHierarchicalMultiCriterionInput()
+	case "studios":
+		performerFilter.Studios, err = criterion.asHierarchicalMultiCriterionInput()
+
+	//StringCriterionInput
+	case "name":
+		performerFilter.Name, err = criterion.asStringCriterionInput()
+	case "disambiguation":
+		performerFilter.Disambiguation, err = criterion.asStringCriterionInput()
+	case "details":
+		performerFilter.Details, err = criterion.asStringCriterionInput()
+	case "ethnicity":
+		performerFilter.Ethnicity, err = criterion.asStringCriterionInput()
+	case "country":
+		performerFilter.Country, err = criterion.asStringCriterionInput()
+	case "eye_color":
+		performerFilter.Eye_color, err = criterion.asStringCriterionInput()
+	case "hair_color":
+		performerFilter.Hair_color, err = criterion.asStringCriterionInput()
+	case "measurements":
+		performerFilter.Measurements, err = criterion.asStringCriterionInput()
+	case "fake_tits":
+		performerFilter.Fake_tits, err = criterion.asStringCriterionInput()
+	case "career_length":
+		performerFilter.Career_length, err = criterion.asStringCriterionInput()
+	case "tattoos":
+		performerFilter.Tattoos, err = criterion.asStringCriterionInput()
+	case "piercings":
+		performerFilter.Piercings, err = criterion.asStringCriterionInput()
+	case "aliases":
+		performerFilter.Aliases, err = criterion.asStringCriterionInput()
+	case "url":
+		performerFilter.Url, err = criterion.asStringCriterionInput()
+
+	//IntCriterionInput
+	case "birth_year":
+		performerFilter.Birth_year, err = criterion.asIntCriterionInput()
+	case "death_year":
+		performerFilter.Death_year, err = criterion.asIntCriterionInput()
+	case "age":
+		performerFilter.Age, err = criterion.asIntCriterionInput()
+	case "height_cm":
+		performerFilter.Height_cm, err = criterion.asIntCriterionInput()
+	case "weight":
+		performerFilter.Weight, err = criterion.asIntCriterionInput()
+	case "rating", "rating100":
+		err = criterion.setRatingCriterion(name, &performerFilter.Rating100)
+	case "tag_count":
+		performerFilter.Tag_count, err = criterion.asIntCriterionInput()
+	case "scene_count":
+		performerFilter.Scene_count, err = criterion.asIntCriterionInput()
+	case "image_count":
+		performerFilter.Image_count, err = criterion.asIntCriterionInput()
+	case "gallery_count":
+		performerFilter.Gallery_count, err = criterion.asIntCriterionInput()
+	case "o_counter":
+		performerFilter.O_counter, err = criterion.asIntCriterionInput()
+
+	//bool
+	case "filter_favorites":
+		performerFilter.Filter_favorites, err = criterion.asBool()
+	case "ignore_auto_tag":
+		performerFilter.Ignore_auto_tag, err = criterion.asBool()
+
+	//string
+	case "is_missing":
+		performerFilter.Is_missing, err = criterion.asString()
+
+	//GenderCriterionInput
+	case "gender":
+		performerFilter.Gender, err = criterion.asGenderCriterionInput()
+
+	//MultiCriterionInput
+	case "performers":
+		performerFilter.Performers, err = criterion.asMultiCriterionInput()
+
+	//TimestampCriterionInput
+	case "created_at":
+		performerFilter.Created_at, err = criterion.asTimestampCriterionInput()
+	case "updated_at":
+		performerFilter.Updated_at, err = criterion.asTimestampCriterionInput()
+
+	//DateCriterionInput
+	case "birthdate":
+		performerFilter.Birthdate, err = criterion.asDateCriterionInput()
+	case "death_date":
+		performerFilter.Death_date, err = criterion.asDateCriterionInput()
+
+	//StashIDCriterionInput
+	case "stash_id_endpoint":
+		performerFilter.Stash_id_endpoint, err = criterion.asStashIDCriterionInput()
+
+	default:
+		log.Ctx(ctx).Warn().Str("type", name).Interface("value", criterion.Value).Msg("Ignoring unsupported criterion")
+		return errUnsupportedCriterion
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse criterion (%v): %w", criterion, err)
+	}
+	return nil
+}
+
+func setStudioFilterCriterion(ctx context.Context, name string, criterionRaw any, studioFilter *gql.StudioFilterType) error {
+	criterion, err := newJsonCriterion(criterionRaw)
+	if err != nil {
+		return fmt.Errorf("criterion '%s': %w", name, err)
+	}
+	switch name {
+	//StringCriterionInput
+	case "name":
+		studioFilter.Name, err = criterion.asStringCriterionInput()
+	case "details":
+		studioFilter.Details, err = criterion.asStringCriterionInput()
+	case "url":
+		studioFilter.Url, err = criterion.asStringCriterionInput()
+	case "aliases":
+		studioFilter.Aliases, err = criterion.asStringCriterionInput()
+
+	//IntCriterionInput
+	case "rating", "rating100":
+		err = criterion.setRatingCriterion(name, &studioFilter.Rating100)
+	case "scene_count":
+		studioFilter.Scene_count, err = criterion.asIntCriterionInput()
+	case "image_count":
+		studioFilter.Image_count, err = criterion.asIntCriterionInput()
+	case "gallery_count":
+		studioFilter.Gallery_count, err = criterion.asIntCriterionInput()
+
+	//MultiCriterionInput
+	case "parents":
+		studioFilter.Parents, err = criterion.asMultiCriterionInput()
+
+	//bool
+	case "ignore_auto_tag":
+		studioFilter.Ignore_auto_tag, err = criterion.asBool()
+
+	//string
+	case "is_missing":
+		studioFilter.Is_missing, err = criterion.asString()
+
+	//TimestampCriterionInput
+	case "created_at":
+		studioFilter.Created_at, err = criterion.asTimestampCriterionInput()
+	case "updated_at":
+		studioFilter.Updated_at, err = criterion.asTimestampCriterionInput()
+
+	//StashIDCriterionInput
+	case "stash_id_endpoint":
+		studioFilter.Stash_id_endpoint, err = criterion.asStashIDCriterionInput()
+
+	default:
+		log.Ctx(ctx).Warn().Str("type", name).Interface("value", criterion.Value).Msg("Ignoring unsupported criterion")
+		return errUnsupportedCriterion
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse criterion (%v): %w", criterion, err)
+	}
+	return nil
+}
+
+func setMovieFilterCriterion(ctx context.Context, name string, criterionRaw any, movieFilter *gql.MovieFilterType) error {
+	criterion, err := newJsonCriterion(criterionRaw)
+	if err != nil {
+		return fmt.Errorf("criterion '%s': %w", name, err)
+	}
+	switch name {
+	//HierarchicalMultiCriterionInput
+	case "studios":
+		movieFilter.Studios, err = criterion.asHierarchicalMultiCriterionInput()
+
+	//StringCriterionInput
+	case "name":
+		movieFilter.Name, err = criterion.asStringCriterionInput()
+	case "director":
+		movieFilter.Director, err = criterion.asStringCriterionInput()
+	case "synopsis":
+		movieFilter.Synopsis, err = criterion.asStringCriterionInput()
+	case "url":
+		movieFilter.Url, err = criterion.asStringCriterionInput()
+
+	//IntCriterionInput
+	case "duration":
+		movieFilter.Duration, err = criterion.asIntCriterionInput()
+	case "rating", "rating100":
+		err = criterion.setRatingCriterion(name, &movieFilter.Rating100)
+
+	//MultiCriterionInput
+	case "performers":
+		movieFilter.Performers, err = criterion.asMultiCriterionInput()
+
+	//string
+	case "is_missing":
+		movieFilter.Is_missing, err = criterion.asString()
+
+	//TimestampCriterionInput
+	case "created_at":
+		movieFilter.Created_at, err = criterion.asTimestampCriterionInput()
+	case "updated_at":
+		movieFilter.Updated_at, err = criterion.asTimestampCriterionInput()
+
+	//DateCriterionInput
+	case "date":
+		movieFilter.Date, err = criterion.asDateCriterionInput()
+
+	default:
+		log.Ctx(ctx).Warn().Str("type", name).Interface("value", criterion.Value).Msg("Ignoring unsupported criterion")
+		return errUnsupportedCriterion
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse criterion (%v): %w", criterion, err)
+	}
+	return nil
+}
+
+func setSceneMarkerFilterCriterion(ctx context.Context, name string, criterionRaw any, sceneMarkerFilter *gql.SceneMarkerFilterType) error {
+	criterion, err := newJsonCriterion(criterionRaw)
+	if err != nil {
+		return fmt.Errorf("criterion '%s': %w", name, err)
+	}
+	switch name {
+	//HierarchicalMultiCriterionInput
+	case "tags":
+		sceneMarkerFilter.Tags, err = criterion.asHierarchicalMultiCriterionInput()
+	case "scene_tags":
+		sceneMarkerFilter.Scene_tags, err = criterion.asHierarchicalMultiCriterionInput()
+
+	//MultiCriterionInput
+	case "performers":
+		sceneMarkerFilter.Performers, err = criterion.asMultiCriterionInput()
+
+	//TimestampCriterionInput
+	case "created_at":
+		sceneMarkerFilter.Created_at, err = criterion.asTimestampCriterionInput()
+	case "updated_at":
+		sceneMarkerFilter.Updated_at, err = criterion.asTimestampCriterionInput()
+	case "scene_created_at":
+		sceneMarkerFilter.Scene_created_at, err = criterion.asTimestampCriterionInput()
+	case "scene_updated_at":
+		sceneMarkerFilter.Scene_updated_at, err = criterion.asTimestampCriterionInput()
+
+	//DateCriterionInput
+	case "scene_date":
+		sceneMarkerFilter.Scene_date, err = criterion.asDateCriterionInput()
+
+	default:
+		log.Ctx(ctx).Warn().Str("type", name).Interface("value", criterion.Value).Msg("Ignoring unsupported criterion")
+		return errUnsupportedCriterion
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse criterion (%v): %w", criterion, err)
+	}
+	return nil
+}
+
+// asGenderCriterionInput accepts both the enum value, e.g. TRANSGENDER_FEMALE, and the label
+// stored by older versions of Stash, e.g. "Transgender Female".
+func (c jsonCriterion) asGenderCriterionInput() (*gql.GenderCriterionInput, error) {
+	s, ok := c.Value.(string)
+	if !ok {
+		return nil, newUnexpectedTypeErr(c.Value)
+	}
+	gender := strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToUpper(s))
+	return &gql.GenderCriterionInput{
+		Value:    gql.GenderEnum(gender),
+		Modifier: gql.CriterionModifier(c.Modifier),
+	}, nil
+}
@@ -0,0 +1,187 @@
+package filter
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"stash-vr/internal/logger"
+	"stash-vr/internal/stash/gql"
+	"testing"
+)
+
+// testSavedFilter returns a saved filter of mode with the criteria of objectFilter, sorted by name.
+func testSavedFilter(t *testing.T, mode gql.FilterMode, objectFilter string) gql.SavedFilterParts {
+	t.Helper()
+	savedFilter := gql.SavedFilterParts{
+		Mode:        mode,
+		Find_filter: &gql.SavedFilterPartsFind_filterSavedFindFilterType{Sort: "name"},
+	}
+	if err := json.Unmarshal([]byte(objectFilter), &savedFilter.Object_filter); err != nil {
+		t.Fatal(err)
+	}
+	return savedFilter
+}
+
+func TestSavedFilterToPerformerFilter(t *testing.T) {
+	tests := []struct {
+		name            string
+		objectFilter    string
+		want            gql.PerformerFilterType
+		wantUnsupported []string
+	}{
+		{
+			"gender label",
+			`{"gender": {"modifier": "EQUALS", "value": "Transgender Female"}}`,
+			gql.PerformerFilterType{Gender: &gql.GenderCriterionInput{Value: gql.GenderEnumTransgenderFemale, Modifier: gql.CriterionModifierEquals}},
+			nil,
+		},
+		{
+			"rating 1-5",
+			`{"rating": {"modifier": "GREATER_THAN", "value": 4}}`,
+			gql.PerformerFilterType{Rating100: &gql.IntCriterionInput{Value: 80, Modifier: gql.CriterionModifierGreaterThan}},
+			nil,
+		},
+		{
+			"tags and name",
+			`{"tags": {"modifier": "INCLUDES_ALL", "value": {"items": [{"id": "3", "label": "Blonde"}], "depth": -1}},
+			  "name": {"modifier": "INCLUDES", "value": "Jane"}}`,
+			gql.PerformerFilterType{
+				Tags: &gql.HierarchicalMultiCriterionInput{Value: []string{"3"}, Modifier: gql.CriterionModifierIncludesAll, Depth: -1, Excludes: []string{}},
+				Name: &gql.StringCriterionInput{Value: "Jane", Modifier: gql.CriterionModifierIncludes},
+			},
+			nil,
+		},
+		{
+			"favorites and birthdate",
+			`{"filter_favorites": {"modifier": "EQUALS", "value": "true"},
+			  "birthdate": {"modifier": "BETWEEN", "value": {"value": "1990-01-01", "value2": "1999-12-31"}}}`,
+			gql.PerformerFilterType{
+				Filter_favorites: true,
+				Birthdate:        &gql.DateCriterionInput{Value: "1990-01-01", Value2: "1999-12-31", Modifier: gql.CriterionModifierBetween},
+			},
+			nil,
+		},
+		{
+			"unsupported",
+			`{"scene_count": {"modifier": "GREATER_THAN", "value": {"value": 10}}, "frobnicate": {"modifier": "EQUALS", "value": "x"}}`,
+			gql.PerformerFilterType{Scene_count: &gql.IntCriterionInput{Value: 10, Modifier: gql.CriterionModifierGreaterThan}},
+			[]string{"frobnicate"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := SavedFilterToPerformerFilter(context.Background(), testSavedFilter(t, gql.FilterModePerformers, tt.objectFilter))
+			if err != nil {
+				t.Fatalf("SavedFilterToPerformerFilter() error = %v", err)
+			}
+			if !reflect.DeepEqual(f.PerformerFilter, tt.want) {
+				t.Errorf("PerformerFilter = %s, want %s", logger.AsJsonStr(f.PerformerFilter), logger.AsJsonStr(tt.want))
+			}
+			sort.Strings(f.Unsupported)
+			if !reflect.DeepEqual(f.Unsupported, tt.wantUnsupported) {
+				t.Errorf("Unsupported = %v, want %v", f.Unsupported, tt.wantUnsupported)
+			}
+			if f.FilterOpts.Per_page != -1 || f.FilterOpts.Sort != "name" || f.FilterOpts.Direction != gql.SortDirectionEnumAsc {
+				t.Errorf("FilterOpts = %+v, want all sorted by name ascending", f.FilterOpts)
+			}
+		})
+	}
+}
+
+func TestSavedFilterToStudioFilter(t *testing.T) {
+	tests := []struct {
+		name            string
+		objectFilter    string
+		want            gql.StudioFilterType
+		wantUnsupported []string
+	}{
+		{
+			"parents",
+			`{"parents": {"modifier": "INCLUDES", "value": [{"id": "5", "label": "Network"}]}}`,
+			gql.StudioFilterType{Parents: &gql.MultiCriterionInput{Value: []string{"5"}, Modifier: gql.CriterionModifierIncludes}},
+			nil,
+		},
+		{
+			"rating100 and scene count",
+			`{"rating100": {"modifier": "GREATER_THAN", "value": {"value": 60}}, "scene_count": {"modifier": "LESS_THAN", "value": 100}}`,
+			gql.StudioFilterType{
+				Rating100:   &gql.IntCriterionInput{Value: 60, Modifier: gql.CriterionModifierGreaterThan},
+				Scene_count: &gql.IntCriterionInput{Value: 100, Modifier: gql.CriterionModifierLessThan},
+			},
+			nil,
+		},
+		{
+			"unsupported",
+			`{"tags": {"modifier": "INCLUDES", "value": {"items": [{"id": "1"}]}}}`,
+			gql.StudioFilterType{},
+			[]string{"tags"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := SavedFilterToStudioFilter(context.Background(), testSavedFilter(t, gql.FilterModeStudios, tt.objectFilter))
+			if err != nil {
+				t.Fatalf("SavedFilterToStudioFilter() error = %v", err)
+			}
+			if !reflect.DeepEqual(f.StudioFilter, tt.want) {
+				t.Errorf("StudioFilter = %s, want %s", logger.AsJsonStr(f.StudioFilter), logger.AsJsonStr(tt.want))
+			}
+			if !reflect.DeepEqual(f.Unsupported, tt.wantUnsupported) {
+				t.Errorf("Unsupported = %v, want %v", f.Unsupported, tt.wantUnsupported)
+			}
+		})
+	}
+}
+
+func TestSavedFilterToMovieFilter(t *testing.T) {
+	tests := []struct {
+		name            string
+		mode            gql.FilterMode
+		objectFilter    string
+		want            gql.MovieFilterType
+		wantUnsupported []string
+	}{
+		{
+			"studios and duration",
+			gql.FilterModeMovies,
+			`{"studios": {"modifier": "INCLUDES", "value": {"items": [{"id": "2", "label": "B"}], "depth": 0}},
+			  "duration": {"modifier": "GREATER_THAN", "value": {"value": 3600}}}`,
+			gql.MovieFilterType{
+				Studios:  &gql.HierarchicalMultiCriterionInput{Value: []string{"2"}, Modifier: gql.CriterionModifierIncludes, Excludes: []string{}},
+				Duration: &gql.IntCriterionInput{Value: 3600, Modifier: gql.CriterionModifierGreaterThan},
+			},
+			nil,
+		},
+		{
+			"groups mode",
+			"GROUPS",
+			`{"date": {"modifier": "GREATER_THAN", "value": {"value": "2020-01-01"}}, "frobnicate": {"modifier": "EQUALS", "value": "x"}}`,
+			gql.MovieFilterType{Date: &gql.DateCriterionInput{Value: "2020-01-01", Modifier: gql.CriterionModifierGreaterThan}},
+			[]string{"frobnicate"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := SavedFilterToMovieFilter(context.Background(), testSavedFilter(t, tt.mode, tt.objectFilter))
+			if err != nil {
+				t.Fatalf("SavedFilterToMovieFilter() error = %v", err)
+			}
+			if !reflect.DeepEqual(f.MovieFilter, tt.want) {
+				t.Errorf("MovieFilter = %s, want %s", logger.AsJsonStr(f.MovieFilter), logger.AsJsonStr(tt.want))
+			}
+			if !reflect.DeepEqual(f.Unsupported, tt.wantUnsupported) {
+				t.Errorf("Unsupported = %v, want %v", f.Unsupported, tt.wantUnsupported)
+			}
+		})
+	}
+}
+
+func TestSavedFilterToObjectFilter_invalid(t *testing.T) {
+	if _, err := SavedFilterToPerformerFilter(context.Background(), testSavedFilter(t, gql.FilterModeScenes, `{}`)); err == nil {
+		t.Error("SavedFilterToPerformerFilter() error = nil, want error for a scene filter")
+	}
+	if _, err := SavedFilterToStudioFilter(context.Background(), testSavedFilter(t, gql.FilterModeStudios, `{"rating100": {"modifier": "EQUALS", "value": "high"}}`)); err == nil {
+		t.Error("SavedFilterToStudioFilter() error = nil, want error for a rating that isn't a number")
+	}
+}
@@ -0,0 +1,454 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"stash-vr/internal/stash/filter"
+	"stash-vr/internal/stash/gql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the kind of object a name is resolved to.
+type Kind string
+
+const (
+	KindTag       Kind = "tag"
+	KindStudio    Kind = "studio"
+	KindPerformer Kind = "performer"
+	KindMovie     Kind = "movie"
+)
+
+// ErrNotFound is returned by a Resolver for unknown names.
+var ErrNotFound = errors.New("not found")
+
+// Resolver returns the id of the tag, studio, performer or movie with the name.
+type Resolver func(ctx context.Context, kind Kind, name string) (string, error)
+
+// Compile parses and compiles a query into a filter of scenes, resolving names through r.
+func Compile(ctx context.Context, s string, r Resolver) (filter.Filter, error) {
+	q, err := Parse(s)
+	if err != nil {
+		return filter.Filter{}, err
+	}
+	return q.Compile(ctx, r)
+}
+
+// Compile compiles q into a filter of scenes, resolving names through r.
+func (q Query) Compile(ctx context.Context, r Resolver) (filter.Filter, error) {
+	c := compiler{ctx: ctx, resolve: r}
+	f := filter.Filter{FilterOpts: gql.FindFilterType{
+		Per_page:  -1,
+		Sort:      q.Sort,
+		Direction: filter.DirectionOrDefault(q.Sort, q.Direction),
+	}}
+	if q.Limit > 0 {
+		f.FilterOpts.Per_page = q.Limit
+	}
+	if q.Expr == nil {
+		return f, nil
+	}
+
+	var search []string
+	expr := c.collectSearch(q.Expr, &search)
+	f.FilterOpts.Q = strings.Join(search, " ")
+	if expr == nil {
+		return f, nil
+	}
+	sceneFilter, err := c.compile(expr)
+	if err != nil {
+		return filter.Filter{}, err
+	}
+	f.SceneFilter = *sceneFilter
+	return f, nil
+}
+
+type compiler struct {
+	ctx     context.Context
+	resolve Resolver
+}
+
+// collectSearch removes the search texts combined by AND at the top of the expression, Stash takes a single search
+// term per query. It returns what's left of the expression, nil if nothing.
+func (c compiler) collectSearch(n Node, search *[]string) Node {
+	switch n := n.(type) {
+	case Search:
+		*search = append(*search, n.Text)
+		return nil
+	case And:
+		left, right := c.collectSearch(n.Left, search), c.collectSearch(n.Right, search)
+		if left == nil {
+			return right
+		}
+		if right == nil {
+			return left
+		}
+		return And{Left: left, Right: right, column: n.column}
+	}
+	return n
+}
+
+func (c compiler) compile(n Node) (*gql.SceneFilterType, error) {
+	switch n := n.(type) {
+	case Term:
+		return c.compileTerm(n)
+	case Search:
+		return nil, errorAt(n.column, "search text \"%s\" can only be combined with AND", n.Text)
+	case Not:
+		operand, err := c.compile(n.Operand)
+		if err != nil {
+			return nil, err
+		}
+		return &gql.SceneFilterType{NOT: operand}, nil
+	case And:
+		left, right, err := c.compileBoth(n.Left, n.Right)
+		if err != nil {
+			return nil, err
+		}
+		f, ok := and(left, right)
+		if !ok {
+			return nil, errorAt(n.column, "can't combine these AND operands, Stash allows one AND, OR or NOT per level")
+		}
+		return f, nil
+	case Or:
+		left, right, err := c.compileBoth(n.Left, n.Right)
+		if err != nil {
+			return nil, err
+		}
+		f, ok := or(left, right)
+		if !ok {
+			return nil, errorAt(n.column, "can't combine these OR operands, Stash allows one AND, OR or NOT per level")
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("unknown node %T", n)
+}
+
+func (c compiler) compileBoth(l Node, r Node) (*gql.SceneFilterType, *gql.SceneFilterType, error) {
+	left, err := c.compile(l)
+	if err != nil {
+		return nil, nil, err
+	}
+	right, err := c.compile(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return left, right, nil
+}
+
+// Stash combines the criteria of a filter with AND and allows one sub-filter, AND, OR or NOT, per level:
+// {criteria, OR: sub} is "criteria OR sub", {criteria, NOT: sub} is "criteria AND NOT sub".
+type subFilter int
+
+const (
+	subNone subFilter = iota
+	subAnd
+	subOr
+	subNot
+)
+
+func subOf(f *gql.SceneFilterType) subFilter {
+	switch {
+	case f.AND != nil:
+		return subAnd
+	case f.OR != nil:
+		return subOr
+	case f.NOT != nil:
+		return subNot
+	}
+	return subNone
+}
+
+// and returns f AND g, or false if it can't be expressed with one sub-filter per level.
+func and(f *gql.SceneFilterType, g *gql.SceneFilterType) (*gql.SceneFilterType, bool) {
+	switch {
+	case subOf(f) == subNone && subOf(g) == subNone && mergeCriteria(f, g):
+		return f, true
+	case subOf(f) == subNone && isEmpty(g):
+		f.AND, f.NOT = g.AND, g.NOT
+		return f, true
+	case subOf(f) == subNone:
+		f.AND = g
+		return f, true
+	case subOf(g) == subNone:
+		g.AND = f
+		return g, true
+	case subOf(f) == subAnd:
+		sub, ok := and(f.AND, g)
+		f.AND = sub
+		return f, ok
+	case subOf(g) == subAnd:
+		return and(g, f)
+	case subOf(f) == subNot && subOf(g) == subNot:
+		// f AND NOT a AND g AND NOT b is f AND g AND NOT (a OR b)
+		fNot, gNot := f.NOT, g.NOT
+		f.NOT, g.NOT = nil, nil
+		if !mergeCriteria(f, g) {
+			return nil, false
+		}
+		sub, ok := or(fNot, gNot)
+		f.NOT = sub
+		return f, ok
+	case subOf(f) == subNot:
+		// f AND NOT a AND g is f AND NOT (a OR NOT g)
+		sub, ok := or(f.NOT, &gql.SceneFilterType{NOT: g})
+		f.NOT = sub
+		return f, ok
+	case subOf(g) == subNot:
+		return and(g, f)
+	}
+	return nil, false
+}
+
+// or returns f OR g, or false if it can't be expressed with one sub-filter per level.
+func or(f *gql.SceneFilterType, g *gql.SceneFilterType) (*gql.SceneFilterType, bool) {
+	switch {
+	case subOf(f) == subNone && !isEmpty(f):
+		f.OR = g
+		return f, true
+	case subOf(f) == subOr:
+		sub, ok := or(f.OR, g)
+		f.OR = sub
+		return f, ok
+	case subOf(g) == subNone && !isEmpty(g) || subOf(g) == subOr:
+		return or(g, f)
+	}
+	return nil, false
+}
+
+// isEmpty reports whether f has no criteria of its own, such a filter can't be the left side of OR.
+func isEmpty(f *gql.SceneFilterType) bool {
+	v := reflect.ValueOf(f).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if name := v.Type().Field(i).Name; name == "AND" || name == "OR" || name == "NOT" {
+			continue
+		}
+		if !v.Field(i).IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeCriteria sets the criteria of g in f, if they don't set the same criteria.
+func mergeCriteria(f *gql.SceneFilterType, g *gql.SceneFilterType) bool {
+	fv, gv := reflect.ValueOf(f).Elem(), reflect.ValueOf(g).Elem()
+	for i := 0; i < fv.NumField(); i++ {
+		if !fv.Field(i).IsZero() && !gv.Field(i).IsZero() {
+			return false
+		}
+	}
+	for i := 0; i < fv.NumField(); i++ {
+		if !gv.Field(i).IsZero() {
+			fv.Field(i).Set(gv.Field(i))
+		}
+	}
+	return true
+}
+
+func (c compiler) compileTerm(t Term) (*gql.SceneFilterType, error) {
+	f := &gql.SceneFilterType{}
+	var err error
+	switch t.Field {
+	case "tag", "tags":
+		f.Tags, err = c.hierarchicalCriterion(t, KindTag)
+	case "studio", "studios":
+		f.Studios, err = c.hierarchicalCriterion(t, KindStudio)
+	case "performer", "performers":
+		f.Performers, err = c.multiCriterion(t, KindPerformer)
+	case "movie", "movies", "group", "groups":
+		f.Movies, err = c.multiCriterion(t, KindMovie)
+
+	case "title":
+		f.Title, err = stringCriterion(t)
+	case "path":
+		f.Path, err = stringCriterion(t)
+	case "details":
+		f.Details, err = stringCriterion(t)
+	case "director":
+		f.Director, err = stringCriterion(t)
+	case "code":
+		f.Code, err = stringCriterion(t)
+
+	case "rating", "rating100":
+		f.Rating100, err = intCriterion(t)
+	case "o", "o_counter":
+		f.O_counter, err = intCriterion(t)
+	case "play_count", "plays":
+		f.Play_count, err = intCriterion(t)
+	case "duration":
+		f.Duration, err = intCriterion(t)
+	case "performer_count":
+		f.Performer_count, err = intCriterion(t)
+	case "tag_count":
+		f.Tag_count, err = intCriterion(t)
+	case "resume_time":
+		f.Resume_time, err = intCriterion(t)
+
+	case "organized":
+		f.Organized, err = boolCriterion(t)
+	case "interactive":
+		f.Interactive, err = boolCriterion(t)
+	case "performer_favorite":
+		f.Performer_favorite, err = boolCriterion(t)
+
+	case "date":
+		f.Date, err = dateCriterion(t)
+	case "created_at":
+		f.Created_at, err = timestampCriterion(t)
+	case "updated_at":
+		f.Updated_at, err = timestampCriterion(t)
+
+	default:
+		return nil, errorAt(t.column, "unknown field '%s'", t.Field)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (c compiler) resolveId(t Term, kind Kind) (string, error) {
+	id, err := c.resolve(c.ctx, kind, t.Value)
+	if errors.Is(err, ErrNotFound) {
+		return "", errorAt(t.valueColumn, "%s \"%s\" not found", kind, t.Value)
+	}
+	if err != nil {
+		return "", errorAt(t.valueColumn, "%s \"%s\": %v", kind, t.Value, err)
+	}
+	return id, nil
+}
+
+func includesModifier(t Term) (gql.CriterionModifier, error) {
+	switch t.Op {
+	case ":", "=":
+		return gql.CriterionModifierIncludes, nil
+	case "!=":
+		return gql.CriterionModifierExcludes, nil
+	}
+	return "", errorAt(t.column, "%s supports ':' and '!=', got '%s'", t.Field, t.Op)
+}
+
+func (c compiler) hierarchicalCriterion(t Term, kind Kind) (*gql.HierarchicalMultiCriterionInput, error) {
+	modifier, err := includesModifier(t)
+	if err != nil {
+		return nil, err
+	}
+	id, err := c.resolveId(t, kind)
+	if err != nil {
+		return nil, err
+	}
+	return &gql.HierarchicalMultiCriterionInput{Value: []string{id}, Modifier: modifier, Excludes: []string{}}, nil
+}
+
+func (c compiler) multiCriterion(t Term, kind Kind) (*gql.MultiCriterionInput, error) {
+	modifier, err := includesModifier(t)
+	if err != nil {
+		return nil, err
+	}
+	id, err := c.resolveId(t, kind)
+	if err != nil {
+		return nil, err
+	}
+	return &gql.MultiCriterionInput{Value: []string{id}, Modifier: modifier}, nil
+}
+
+func stringCriterion(t Term) (*gql.StringCriterionInput, error) {
+	var modifier gql.CriterionModifier
+	switch t.Op {
+	case ":":
+		modifier = gql.CriterionModifierIncludes
+	case "=":
+		modifier = gql.CriterionModifierEquals
+	case "!=":
+		modifier = gql.CriterionModifierExcludes
+	default:
+		return nil, errorAt(t.column, "%s supports ':', '=' and '!=', got '%s'", t.Field, t.Op)
+	}
+	return &gql.StringCriterionInput{Value: t.Value, Modifier: modifier}, nil
+}
+
+// intCriterion compares numbers, Stash has no greater or less than or equal so those are adjusted by one.
+func intCriterion(t Term) (*gql.IntCriterionInput, error) {
+	value, err := strconv.Atoi(t.Value)
+	if err != nil {
+		return nil, errorAt(t.valueColumn, "%s must be a number, got \"%s\"", t.Field, t.Value)
+	}
+	input := &gql.IntCriterionInput{Value: value}
+	switch t.Op {
+	case ":", "=":
+		input.Modifier = gql.CriterionModifierEquals
+	case "!=":
+		input.Modifier = gql.CriterionModifierNotEquals
+	case ">":
+		input.Modifier = gql.CriterionModifierGreaterThan
+	case ">=":
+		input.Modifier, input.Value = gql.CriterionModifierGreaterThan, value-1
+	case "<":
+		input.Modifier = gql.CriterionModifierLessThan
+	case "<=":
+		input.Modifier, input.Value = gql.CriterionModifierLessThan, value+1
+	}
+	return input, nil
+}
+
+func boolCriterion(t Term) (*bool, error) {
+	b, err := strconv.ParseBool(t.Value)
+	if err != nil {
+		return nil, errorAt(t.valueColumn, "%s must be true or false, got \"%s\"", t.Field, t.Value)
+	}
+	switch t.Op {
+	case ":", "=":
+	case "!=":
+		b = !b
+	default:
+		return nil, errorAt(t.column, "%s supports ':' and '!=', got '%s'", t.Field, t.Op)
+	}
+	return &b, nil
+}
+
+const dateLayout = "2006-01-02"
+
+// dateCriterion compares dates, e.g. date>=2023-01-01, greater or less than or equal are adjusted by a day.
+func dateCriterion(t Term) (*gql.DateCriterionInput, error) {
+	date, err := time.Parse(dateLayout, t.Value)
+	if err != nil {
+		return nil, errorAt(t.valueColumn, "%s must be a date as YYYY-MM-DD, got \"%s\"", t.Field, t.Value)
+	}
+	input := &gql.DateCriterionInput{Value: t.Value}
+	switch t.Op {
+	case ":", "=":
+		input.Modifier = gql.CriterionModifierEquals
+	case "!=":
+		input.Modifier = gql.CriterionModifierNotEquals
+	case ">":
+		input.Modifier = gql.CriterionModifierGreaterThan
+	case ">=":
+		input.Modifier, input.Value = gql.CriterionModifierGreaterThan, date.AddDate(0, 0, -1).Format(dateLayout)
+	case "<":
+		input.Modifier = gql.CriterionModifierLessThan
+	case "<=":
+		input.Modifier, input.Value = gql.CriterionModifierLessThan, date.AddDate(0, 0, 1).Format(dateLayout)
+	}
+	return input, nil
+}
+
+// timestampCriterion compares timestamps with a date, only before (<) or after (>) it.
+func timestampCriterion(t Term) (*gql.TimestampCriterionInput, error) {
+	if _, err := time.Parse(dateLayout, t.Value); err != nil {
+		return nil, errorAt(t.valueColumn, "%s must be a date as YYYY-MM-DD, got \"%s\"", t.Field, t.Value)
+	}
+	input := &gql.TimestampCriterionInput{Value: t.Value}
+	switch t.Op {
+	case ">":
+		input.Modifier = gql.CriterionModifierGreaterThan
+	case "<":
+		input.Modifier = gql.CriterionModifierLessThan
+	default:
+		return nil, errorAt(t.column, "%s supports '>' and '<', got '%s'", t.Field, t.Op)
+	}
+	return input, nil
+}
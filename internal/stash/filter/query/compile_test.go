@@ -0,0 +1,105 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"stash-vr/internal/stash/gql"
+	"testing"
+)
+
+var testIds = map[Kind]map[string]string{
+	KindTag:       {"POV": "1", "Outdoor": "2"},
+	KindStudio:    {"X": "3"},
+	KindPerformer: {"Alice": "4"},
+}
+
+func testResolver(_ context.Context, kind Kind, name string) (string, error) {
+	if id, ok := testIds[kind][name]; ok {
+		return id, nil
+	}
+	return "", ErrNotFound
+}
+
+func TestCompile(t *testing.T) {
+	f, err := Compile(context.Background(), `tags:"POV" AND rating>=80 AND NOT studio:"X" beach sort:date desc`, testResolver)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if f.FilterOpts.Q != "beach" || f.FilterOpts.Sort != "date" || f.FilterOpts.Direction != gql.SortDirectionEnumDesc || f.FilterOpts.Per_page != -1 {
+		t.Errorf("FilterOpts = %+v, want q beach sorted by date desc", f.FilterOpts)
+	}
+	sf := f.SceneFilter
+	if sf.Tags == nil || sf.Tags.Value[0] != "1" || sf.Rating100 == nil || sf.Rating100.Value != 79 {
+		t.Errorf("SceneFilter = %+v, want tag 1 and rating100 > 79", sf)
+	}
+	if sf.NOT == nil || sf.NOT.Studios == nil || sf.NOT.Studios.Value[0] != "3" {
+		t.Errorf("NOT = %+v, want studio 3", sf.NOT)
+	}
+}
+
+func TestCompile_defaultOrder(t *testing.T) {
+	f, err := Compile(context.Background(), `performer:Alice`, testResolver)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if f.FilterOpts.Sort != "" || f.FilterOpts.Direction != gql.SortDirectionEnumAsc {
+		t.Errorf("FilterOpts = %+v, want no sort and direction ASC", f.FilterOpts)
+	}
+}
+
+func TestCompile_subFilters(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		check func(f gql.SceneFilterType) bool
+	}{
+		{"or", `tags:POV OR tags:Outdoor`, func(f gql.SceneFilterType) bool {
+			return f.Tags.Value[0] == "1" && f.OR != nil && f.OR.Tags.Value[0] == "2"
+		}},
+		{"same field and", `tags:POV tags:Outdoor`, func(f gql.SceneFilterType) bool {
+			return f.Tags.Value[0] == "1" && f.AND != nil && f.AND.Tags.Value[0] == "2"
+		}},
+		{"and of or", `organized:true AND (tags:POV OR tags:Outdoor)`, func(f gql.SceneFilterType) bool {
+			return *f.Organized && f.AND != nil && f.AND.OR != nil
+		}},
+		{"nots", `NOT tags:POV NOT studio:X`, func(f gql.SceneFilterType) bool {
+			return f.NOT != nil && f.NOT.Tags.Value[0] == "1" && f.NOT.OR != nil && f.NOT.OR.Studios.Value[0] == "3"
+		}},
+		{"not and or", `NOT tags:POV AND (performer:Alice OR organized:false)`, func(f gql.SceneFilterType) bool {
+			return f.NOT != nil && f.NOT.Tags != nil && f.NOT.OR != nil && f.NOT.OR.NOT != nil && f.NOT.OR.NOT.Performers != nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Compile(context.Background(), tt.query, testResolver)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			if !tt.check(f.SceneFilter) {
+				t.Errorf("Compile() = %+v", f.SceneFilter)
+			}
+		})
+	}
+}
+
+func TestCompile_errors(t *testing.T) {
+	tests := []struct {
+		query  string
+		column int
+	}{
+		{`tags:"Nope"`, 6},
+		{`colour:red`, 1},
+		{`rating>high`, 8},
+		{`tags:POV OR beach`, 13},
+		{`(tags:POV OR tags:Outdoor) AND (studio:X OR organized:true)`, 28},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := Compile(context.Background(), tt.query, testResolver)
+			var queryErr *Error
+			if !errors.As(err, &queryErr) || queryErr.Column != tt.column {
+				t.Errorf("Compile() error = %v, want error at column %d", err, tt.column)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+)
+
+// token is a word, quoted string, comparison operator or parenthesis. Column is 1-based and counts characters.
+type token struct {
+	kind   tokenKind
+	text   string
+	column int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenString:
+		return fmt.Sprintf("\"%s\"", t.text)
+	}
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+func (t token) isKeyword(keyword string) bool {
+	return t.kind == tokenWord && t.text == keyword
+}
+
+// Error is a problem at a column of a query.
+type Error struct {
+	Column int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column, e.Msg)
+}
+
+func errorAt(column int, format string, a ...any) *Error {
+	return &Error{Column: column, Msg: fmt.Sprintf(format, a...)}
+}
+
+const operatorRunes = ":=!<>"
+
+func isWordRune(r rune) bool {
+	return !unicode.IsSpace(r) && !strings.ContainsRune(operatorRunes+"()\"", r)
+}
+
+// tokenize splits a query into tokens, quoted strings may escape \" and \\.
+func tokenize(s string) ([]token, error) {
+	runes := []rune(s)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		column := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", column: column})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", column: column})
+			i++
+		case r == '"':
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, errorAt(column, "unterminated string")
+			}
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), column: column})
+			i++
+		case strings.ContainsRune(operatorRunes, r):
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' && (r == '!' || r == '<' || r == '>') {
+				op += "="
+			}
+			if op == "!" {
+				return nil, errorAt(column, "unknown operator '!', did you mean '!='")
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, column: column})
+			i += len(op)
+		default:
+			start := i
+			for i < len(runes) && isWordRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: string(runes[start:i]), column: column})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, column: len(runes) + 1}), nil
+}
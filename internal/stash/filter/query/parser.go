@@ -0,0 +1,256 @@
+// Package query compiles ad-hoc scene filters such as
+//
+//	tags:"POV" AND rating>=80 AND NOT studio:"X" sort:date desc
+//
+// into a filter.Filter. Terms are combined with AND, OR and NOT (upper case) and parentheses, terms next to each
+// other are combined with AND. A word or quoted string without field is searched for like the search box of Stash.
+// sort:<field> [asc|desc] and limit:<n> order and cap the scenes.
+package query
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	keywordAnd = "AND"
+	keywordOr  = "OR"
+	keywordNot = "NOT"
+
+	optionSort  = "sort"
+	optionLimit = "limit"
+)
+
+// Node is a node of the expression of a query.
+type Node interface {
+	Column() int
+}
+
+type And struct {
+	Left, Right Node
+	column      int
+}
+
+type Or struct {
+	Left, Right Node
+	column      int
+}
+
+type Not struct {
+	Operand Node
+	column  int
+}
+
+// Term compares a field with a value, e.g. rating>=80.
+type Term struct {
+	Field       string
+	Op          string
+	Value       string
+	column      int
+	valueColumn int
+}
+
+// Search is text searched for in scenes, e.g. beach.
+type Search struct {
+	Text   string
+	column int
+}
+
+func (n And) Column() int    { return n.column }
+func (n Or) Column() int     { return n.column }
+func (n Not) Column() int    { return n.column }
+func (n Term) Column() int   { return n.column }
+func (n Search) Column() int { return n.column }
+
+// Query is a parsed query. Expr is nil for a query of only options, i.e. all scenes.
+type Query struct {
+	Expr Node
+	// Sort is the sort field, empty for the default order. Direction is "ASC" or "DESC".
+	Sort      string
+	Direction string
+	// Limit caps the number of scenes, 0 is unlimited.
+	Limit int
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// Parse parses a query, problems are returned as *Error telling the column.
+func Parse(s string) (Query, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return Query{}, err
+	}
+	p := parser{tokens: tokens}
+	var q Query
+	for p.peek().kind != tokenEOF {
+		if p.isOption() {
+			if err := p.parseOption(&q); err != nil {
+				return Query{}, err
+			}
+			continue
+		}
+		expr, err := p.parseOr()
+		if err != nil {
+			return Query{}, err
+		}
+		if q.Expr == nil {
+			q.Expr = expr
+		} else {
+			q.Expr = And{Left: q.Expr, Right: expr, column: expr.Column()}
+		}
+		if t := p.peek(); t.kind != tokenEOF && !p.isOption() {
+			return Query{}, errorAt(t.column, "unexpected %s", t)
+		}
+	}
+	return q, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isOption() bool {
+	t := p.peek()
+	return (t.isKeyword(optionSort) || t.isKeyword(optionLimit)) && p.peekAt(1).kind == tokenOp && p.peekAt(1).text == ":"
+}
+
+func (p *parser) parseOption(q *Query) error {
+	name := p.next()
+	p.next()
+	value := p.next()
+	if value.kind != tokenWord && value.kind != tokenString {
+		return errorAt(value.column, "expected a value for %s, got %s", name.text, value)
+	}
+	switch name.text {
+	case optionSort:
+		q.Sort, q.Direction = value.text, "ASC"
+		if t := p.peek(); t.kind == tokenWord && (strings.EqualFold(t.text, "asc") || strings.EqualFold(t.text, "desc")) {
+			q.Direction = strings.ToUpper(p.next().text)
+		}
+	case optionLimit:
+		limit, err := strconv.Atoi(value.text)
+		if err != nil || limit < 0 {
+			return errorAt(value.column, "limit must be a positive number, got %s", value)
+		}
+		q.Limit = limit
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword(keywordOr) {
+		op := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right, column: op.column}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		column := p.peek().column
+		if p.peek().isKeyword(keywordAnd) {
+			p.next()
+		} else if !p.startsOperand() {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right, column: column}
+	}
+}
+
+// startsOperand reports whether the next token starts an operand implicitly combined with AND.
+func (p *parser) startsOperand() bool {
+	t := p.peek()
+	switch t.kind {
+	case tokenString, tokenLParen:
+		return true
+	case tokenWord:
+		return !t.isKeyword(keywordOr) && !p.isOption()
+	}
+	return false
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	t := p.peek()
+	if t.isKeyword(keywordNot) {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Operand: operand, column: t.column}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, errorAt(closing.column, "expected ')' closing '(' at column %d, got %s", t.column, closing)
+		}
+		return expr, nil
+	case tokenString:
+		return Search{Text: t.text, column: t.column}, nil
+	case tokenWord:
+		if t.isKeyword(keywordAnd) || t.isKeyword(keywordOr) {
+			return nil, errorAt(t.column, "expected a term, got %s", t)
+		}
+		if p.isOptionAt(t) {
+			return nil, errorAt(t.column, "%s can't be combined with AND, OR or NOT", t.text)
+		}
+		if p.peek().kind != tokenOp {
+			return Search{Text: t.text, column: t.column}, nil
+		}
+		op := p.next()
+		value := p.next()
+		if value.kind != tokenWord && value.kind != tokenString {
+			return nil, errorAt(value.column, "expected a value after %s, got %s", op, value)
+		}
+		return Term{Field: strings.ToLower(t.text), Op: op.text, Value: value.text, column: t.column, valueColumn: value.column}, nil
+	}
+	return nil, errorAt(t.column, "expected a term, got %s", t)
+}
+
+// isOptionAt reports whether t, just consumed, starts an option.
+func (p *parser) isOptionAt(t token) bool {
+	return (t.isKeyword(optionSort) || t.isKeyword(optionLimit)) && p.peek().kind == tokenOp && p.peek().text == ":"
+}
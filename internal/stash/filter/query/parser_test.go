@@ -0,0 +1,48 @@
+package query
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	q, err := Parse(`tags:"POV" AND rating>=80 AND NOT studio:"X" sort:date desc limit:20`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if q.Sort != "date" || q.Direction != "DESC" || q.Limit != 20 {
+		t.Errorf("options = %s %s %d, want date DESC 20", q.Sort, q.Direction, q.Limit)
+	}
+	and, ok := q.Expr.(And)
+	if !ok {
+		t.Fatalf("Expr = %#v, want And", q.Expr)
+	}
+	if not, ok := and.Right.(Not); !ok || not.Operand.(Term).Value != "X" {
+		t.Errorf("Right = %#v, want NOT studio:X", and.Right)
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	tests := []struct {
+		query  string
+		column int
+	}{
+		{`tags:"POV`, 6},
+		{`tags:"POV" AND`, 15},
+		{`(rating>80 OR organized:true`, 29},
+		{`rating>=`, 9},
+		{`tags:POV AND sort:date`, 14},
+		{`limit:-1`, 7},
+		{`title!x`, 6},
+		{`tags:POV)`, 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			var queryErr *Error
+			if !errors.As(err, &queryErr) || queryErr.Column != tt.column {
+				t.Errorf("Parse() error = %v, want error at column %d", err, tt.column)
+			}
+		})
+	}
+}
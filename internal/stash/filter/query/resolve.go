@@ -0,0 +1,54 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"stash-vr/internal/stash/gql"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// StashResolver resolves names by exact, case-insensitive, match in Stash.
+func StashResolver(client graphql.Client) Resolver {
+	return func(ctx context.Context, kind Kind, name string) (string, error) {
+		var ids []string
+		switch kind {
+		case KindTag:
+			response, err := gql.FindTagByName(ctx, client, name)
+			if err != nil {
+				return "", fmt.Errorf("FindTagByName: %w", err)
+			}
+			for _, t := range response.FindTags.Tags {
+				ids = append(ids, t.Id)
+			}
+		case KindStudio:
+			response, err := gql.FindStudioByName(ctx, client, name)
+			if err != nil {
+				return "", fmt.Errorf("FindStudioByName: %w", err)
+			}
+			for _, s := range response.FindStudios.Studios {
+				ids = append(ids, s.Id)
+			}
+		case KindPerformer:
+			response, err := gql.FindPerformerByName(ctx, client, name)
+			if err != nil {
+				return "", fmt.Errorf("FindPerformerByName: %w", err)
+			}
+			for _, p := range response.FindPerformers.Performers {
+				ids = append(ids, p.Id)
+			}
+		case KindMovie:
+			response, err := gql.FindMovieByName(ctx, client, name)
+			if err != nil {
+				return "", fmt.Errorf("FindMovieByName: %w", err)
+			}
+			for _, m := range response.FindMovies.Movies {
+				ids = append(ids, m.Id)
+			}
+		}
+		if len(ids) == 0 {
+			return "", ErrNotFound
+		}
+		return ids[0], nil
+	}
+}
@@ -0,0 +1,78 @@
+package filter
+
+import "fmt"
+
+// RawSceneFilter converts the criteria of a saved scene filter into a scene filter as they are, e.g.
+// {"modifier": "INCLUDES", "value": {"items": [{"id": "1"}]}} to {"modifier": "INCLUDES", "value": ["1"]}. Unlike
+// SavedFilterToSceneFilter it keeps criteria unknown to stash-vr, it's up to Stash to accept them.
+func RawSceneFilter(objectFilter map[string]any) (map[string]any, error) {
+	f := make(map[string]any, len(objectFilter))
+	for name, raw := range objectFilter {
+		m, ok := raw.(stringAnyMap)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", name, newUnexpectedTypeErr(raw))
+		}
+		if isSubFilterOperator(name) {
+			sub, err := RawSceneFilter(m)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%w", name, err)
+			}
+			f[name] = sub
+			continue
+		}
+		c, err := newJsonCriterion(m)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		f[name] = c.raw(name)
+	}
+	return f, nil
+}
+
+// raw returns the criterion in the form of a scene filter field, boolean criteria are plain booleans and is_missing a
+// plain string.
+func (c jsonCriterion) raw(name string) any {
+	switch v := c.Value.(type) {
+	case string:
+		if v == "true" || v == "false" {
+			return v == "true"
+		}
+		if name == "is_missing" {
+			return v
+		}
+	case []any:
+		return stringAnyMap{"modifier": c.Modifier, "value": rawIds(v)}
+	case stringAnyMap:
+		input := stringAnyMap{"modifier": c.Modifier}
+		if items, ok := v["items"].([]any); ok {
+			input["value"] = rawIds(items)
+			if excluded, ok := v["excluded"].([]any); ok {
+				input["excludes"] = rawIds(excluded)
+			}
+			if depth, ok := v["depth"]; ok {
+				input["depth"] = depth
+			}
+			return input
+		}
+		for _, key := range []string{"value", "value2"} {
+			if value, ok := v[key]; ok {
+				input[key] = value
+			}
+		}
+		return input
+	}
+	return stringAnyMap{"modifier": c.Modifier, "value": c.Value}
+}
+
+// rawIds returns the ids of items, which are ids or {"id": id, "label": name}.
+func rawIds(items []any) []any {
+	ids := make([]any, len(items))
+	for i, item := range items {
+		if m, ok := item.(stringAnyMap); ok {
+			ids[i] = m["id"]
+		} else {
+			ids[i] = item
+		}
+	}
+	return ids
+}
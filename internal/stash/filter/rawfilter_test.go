@@ -0,0 +1,64 @@
+package filter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRawSceneFilter(t *testing.T) {
+	tests := []struct {
+		name         string
+		objectFilter string
+		want         string
+		wantErr      bool
+	}{
+		{
+			"items, value and boolean",
+			`{"tags": {"modifier": "INCLUDES_ALL", "value": {"items": [{"id": "3", "label": "POV"}], "excluded": [{"id": "4"}], "depth": -1}},
+			  "rating100": {"modifier": "BETWEEN", "value": {"value": 60, "value2": 80}},
+			  "organized": {"modifier": "EQUALS", "value": "true"}}`,
+			`{"tags": {"modifier": "INCLUDES_ALL", "value": ["3"], "excludes": ["4"], "depth": -1},
+			  "rating100": {"modifier": "BETWEEN", "value": 60, "value2": 80},
+			  "organized": true}`,
+			false,
+		},
+		{
+			"unknown criteria and sub-filter",
+			`{"is_missing": {"modifier": "EQUALS", "value": "cover"},
+			  "frobnicate": {"modifier": "EQUALS", "value": "x"},
+			  "OR": {"performers": {"modifier": "INCLUDES", "value": [{"id": "5"}]}}}`,
+			`{"is_missing": "cover",
+			  "frobnicate": {"modifier": "EQUALS", "value": "x"},
+			  "OR": {"performers": {"modifier": "INCLUDES", "value": ["5"]}}}`,
+			false,
+		},
+		{"invalid", `{"title": "x"}`, ``, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objectFilter map[string]any
+			if err := json.Unmarshal([]byte(tt.objectFilter), &objectFilter); err != nil {
+				t.Fatal(err)
+			}
+			got, err := RawSceneFilter(objectFilter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RawSceneFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var want map[string]any
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			// compare as JSON, the way it's sent to Stash
+			b, _ := json.Marshal(got)
+			var gotJson map[string]any
+			_ = json.Unmarshal(b, &gotJson)
+			if !reflect.DeepEqual(gotJson, want) {
+				t.Errorf("RawSceneFilter() = %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
@@ -2,8 +2,10 @@ package filter
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"stash-vr/internal/stash/gql"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 )
@@ -11,6 +13,8 @@ import (
 type Filter struct {
 	FilterOpts  gql.FindFilterType
 	SceneFilter gql.SceneFilterType
+	// Unsupported lists the criteria left out of a saved filter, those of sub-filters e.g. as OR.interactive_speed.
+	Unsupported []string
 }
 
 func SavedFilterToSceneFilter(ctx context.Context, savedFilter gql.SavedFilterParts) (Filter, error) {
@@ -25,40 +29,103 @@ func SavedFilterToSceneFilter(ctx context.Context, savedFilter gql.SavedFilterPa
 	return filterQuery, nil
 }
 
+// SortedFilter returns a filter of all scenes by sort and direction, limited to limit scenes. It's the equivalent of
+// Stash's premade front page filters.
+func SortedFilter(sort string, direction gql.SortDirectionEnum, limit int) Filter {
+	return Filter{FilterOpts: gql.FindFilterType{
+		Per_page:  limit,
+		Sort:      sort,
+		Direction: direction,
+	}}
+}
+
+// DirectionOrDefault returns direction, or if empty the default direction of sort: descending for counts, ascending
+// otherwise. Stash rejects an empty direction.
+func DirectionOrDefault(sort string, direction string) gql.SortDirectionEnum {
+	if direction != "" {
+		return gql.SortDirectionEnum(direction)
+	}
+	if strings.HasSuffix(sort, "_count") || sort == "o_counter" {
+		return gql.SortDirectionEnumDesc
+	}
+	return gql.SortDirectionEnumAsc
+}
+
 func parseJsonEncodedFilter(ctx context.Context, stashFilter gql.SavedFilterParts) (Filter, error) {
-	f, err := parseSceneFilterCriteria(ctx, stashFilter.Object_filter)
+	f, unsupported, err := parseSceneFilterCriteria(ctx, stashFilter.Object_filter)
 	if err != nil {
 		return Filter{}, fmt.Errorf("parseSceneFilterCriteria: %w", err)
 	}
 
-	return Filter{FilterOpts: gql.FindFilterType{
+	return Filter{Unsupported: unsupported, FilterOpts: gql.FindFilterType{
+		Q:         stashFilter.Find_filter.Q,
 		Per_page:  -1,
 		Sort:      stashFilter.Find_filter.Sort,
 		Direction: stashFilter.Find_filter.Direction,
 	}, SceneFilter: f}, nil
 }
 
-func parseSceneFilterCriteria(ctx context.Context, jsonCriteria map[string]interface{}) (gql.SceneFilterType, error) {
+// parseSceneFilterCriteria translates the criteria of a saved filter, including nested AND, OR and NOT sub-filters.
+// It returns the names of the criteria left out as unsupported.
+func parseSceneFilterCriteria(ctx context.Context, jsonCriteria map[string]interface{}) (gql.SceneFilterType, []string, error) {
 	f := gql.SceneFilterType{}
-	for name := range jsonCriteria {
-		err := setSceneFilterCriterion(ctx, name, jsonCriteria[name].(map[string]interface{}), &f)
+	var unsupported []string
+	for name, raw := range jsonCriteria {
+		if isSubFilterOperator(name) {
+			subUnsupported, err := setSceneSubFilter(ctx, name, raw, &f)
+			if err != nil {
+				return gql.SceneFilterType{}, nil, fmt.Errorf("setSceneSubFilter: %w", err)
+			}
+			unsupported = append(unsupported, subUnsupported...)
+			continue
+		}
+		err := setSceneFilterCriterion(ctx, name, raw, &f)
+		if errors.Is(err, errUnsupportedCriterion) {
+			unsupported = append(unsupported, name)
+			continue
+		}
 		if err != nil {
-			return gql.SceneFilterType{}, fmt.Errorf("setSceneFilterCriterion: %w", err)
+			return gql.SceneFilterType{}, nil, fmt.Errorf("setSceneFilterCriterion: %w", err)
 		}
 	}
-	return f, nil
+	return f, unsupported, nil
 }
 
-func setSceneFilterCriterion(ctx context.Context, name string, criterionRaw map[string]interface{}, sceneFilter *gql.SceneFilterType) error {
-	var err error
-	criterion := jsonCriterion{Modifier: criterionRaw["modifier"].(string), Value: criterionRaw["value"]}
+func setSceneSubFilter(ctx context.Context, operator string, raw any, sceneFilter *gql.SceneFilterType) ([]string, error) {
+	jsonCriteria, ok := raw.(stringAnyMap)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", operator, newUnexpectedTypeErr(raw))
+	}
+	sub, unsupported, err := parseSceneFilterCriteria(ctx, jsonCriteria)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operator, err)
+	}
+	for i, name := range unsupported {
+		unsupported[i] = operator + "." + name
+	}
+	switch operator {
+	case operatorAnd:
+		sceneFilter.AND = &sub
+	case operatorOr:
+		sceneFilter.OR = &sub
+	case operatorNot:
+		sceneFilter.NOT = &sub
+	}
+	return unsupported, nil
+}
+
+func setSceneFilterCriterion(ctx context.Context, name string, criterionRaw any, sceneFilter *gql.SceneFilterType) error {
+	criterion, err := newJsonCriterion(criterionRaw)
+	if err != nil {
+		return fmt.Errorf("criterion '%s': %w", name, err)
+	}
 	switch name {
 	//HierarchicalMultiCriterionInput
 	case "tags":
 		sceneFilter.Tags, err = criterion.asHierarchicalMultiCriterionInput()
 	case "studios":
 		sceneFilter.Studios, err = criterion.asHierarchicalMultiCriterionInput()
-	case "performer_tags":
+	case "performer_tags", "performerTags":
 		sceneFilter.Performer_tags, err = criterion.asHierarchicalMultiCriterionInput()
 
 	//StringCriterionInput
@@ -72,10 +139,12 @@ func setSceneFilterCriterion(ctx context.Context, name string, criterionRaw map[
 		sceneFilter.Director, err = criterion.asStringCriterionInput()
 	case "oshash":
 		sceneFilter.Oshash, err = criterion.asStringCriterionInput()
-	case "sceneChecksum":
+	case "checksum", "sceneChecksum":
 		sceneFilter.Checksum, err = criterion.asStringCriterionInput()
-	case "phash":
-		sceneFilter.Phash, err = criterion.asStringCriterionInput()
+	case "video_codec":
+		sceneFilter.Video_codec, err = criterion.asStringCriterionInput()
+	case "audio_codec":
+		sceneFilter.Audio_codec, err = criterion.asStringCriterionInput()
 	case "path":
 		sceneFilter.Path, err = criterion.asStringCriterionInput()
 	case "stash_id":
@@ -88,10 +157,8 @@ func setSceneFilterCriterion(ctx context.Context, name string, criterionRaw map[
 	//IntCriterionInput
 	case "id":
 		sceneFilter.Id, err = criterion.asIntCriterionInput()
-	case "rating":
-		sceneFilter.Rating, err = criterion.asIntCriterionInput()
-	case "rating100":
-		sceneFilter.Rating100, err = criterion.asIntCriterionInput()
+	case "rating", "rating100":
+		err = criterion.setRatingCriterion(name, &sceneFilter.Rating100)
 	case "o_counter":
 		sceneFilter.O_counter, err = criterion.asIntCriterionInput()
 	case "duration":
@@ -115,11 +182,15 @@ func setSceneFilterCriterion(ctx context.Context, name string, criterionRaw map[
 
 	//bool
 	case "organized":
-		sceneFilter.Organized, err = criterion.asBool()
-	case "performer_favorite":
-		sceneFilter.Performer_favorite, err = criterion.asBool()
+		sceneFilter.Organized, err = criterion.asBoolPointer()
+	case "performer_favorite", "performerFavorite":
+		sceneFilter.Performer_favorite, err = criterion.asBoolPointer()
 	case "interactive":
-		sceneFilter.Interactive, err = criterion.asBool()
+		sceneFilter.Interactive, err = criterion.asBoolPointer()
+
+	//PhashDistanceCriterionInput, older versions of Stash store a plain phash
+	case "phash", "phash_distance":
+		sceneFilter.Phash_distance, err = criterion.asPhashDistanceCriterionInput()
 
 	//PHashDuplicationCriterionInput
 	case "duplicated":
@@ -130,13 +201,13 @@ func setSceneFilterCriterion(ctx context.Context, name string, criterionRaw map[
 		sceneFilter.Resolution, err = criterion.asResolutionCriterionInput()
 
 	//string
-	case "hasMarkers":
+	case "has_markers", "hasMarkers":
 		sceneFilter.Has_markers, err = criterion.asString()
-	case "sceneIsMissing":
+	case "is_missing", "sceneIsMissing":
 		sceneFilter.Is_missing, err = criterion.asString()
 
 	//MultiCriterionInput
-	case "movies":
+	case "movies", "groups":
 		sceneFilter.Movies, err = criterion.asMultiCriterionInput()
 	case "performers":
 		sceneFilter.Performers, err = criterion.asMultiCriterionInput()
@@ -150,12 +221,14 @@ func setSceneFilterCriterion(ctx context.Context, name string, criterionRaw map[
 	//DateCriterionInput
 	case "date":
 		sceneFilter.Date, err = criterion.asDateCriterionInput()
+
+	//StashIDCriterionInput
 	case "stash_id_endpoint":
 		sceneFilter.Stash_id_endpoint, err = criterion.asStashIDCriterionInput()
-		//StashIDCriterionInput
 
 	default:
 		log.Ctx(ctx).Warn().Str("type", name).Interface("value", criterion.Value).Msg("Ignoring unsupported criterion")
+		return errUnsupportedCriterion
 	}
 	if err != nil {
 		return fmt.Errorf("failed to parse criterion (%v): %w", criterion, err)
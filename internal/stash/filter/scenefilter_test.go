@@ -0,0 +1,129 @@
+package filter
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"stash-vr/internal/stash/gql"
+	"testing"
+)
+
+func TestSavedFilterToSceneFilter(t *testing.T) {
+	objectFilter := `{
+		"tags": {"modifier": "INCLUDES", "value": {"items": [{"id": "1", "label": "POV"}], "excluded": [], "depth": 0}},
+		"organized": {"modifier": "EQUALS", "value": "false"},
+		"phash": {"modifier": "EQUALS", "value": {"value": "abc", "distance": 4}},
+		"OR": {
+			"rating100": {"modifier": "GREATER_THAN", "value": {"value": 80}},
+			"NOT": {"studios": {"modifier": "INCLUDES", "value": {"items": [{"id": "2", "label": "B"}], "depth": -1}}},
+			"frobnicate": {"modifier": "EQUALS", "value": "x"}
+		},
+		"unknown": {"modifier": "EQUALS", "value": "x"}
+	}`
+	savedFilter := gql.SavedFilterParts{
+		Mode:        gql.FilterModeScenes,
+		Find_filter: &gql.SavedFilterPartsFind_filterSavedFindFilterType{Q: "beach", Sort: "date", Direction: gql.SortDirectionEnumDesc},
+	}
+	if err := json.Unmarshal([]byte(objectFilter), &savedFilter.Object_filter); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := SavedFilterToSceneFilter(context.Background(), savedFilter)
+	if err != nil {
+		t.Fatalf("SavedFilterToSceneFilter() error = %v", err)
+	}
+	if f.FilterOpts.Q != "beach" || f.FilterOpts.Sort != "date" {
+		t.Errorf("FilterOpts = %+v, want q beach sorted by date", f.FilterOpts)
+	}
+	if f.SceneFilter.Tags == nil || f.SceneFilter.Tags.Value[0] != "1" {
+		t.Errorf("Tags = %+v, want tag 1", f.SceneFilter.Tags)
+	}
+	if f.SceneFilter.Organized == nil || *f.SceneFilter.Organized {
+		t.Errorf("Organized = %v, want false", f.SceneFilter.Organized)
+	}
+	if p := f.SceneFilter.Phash_distance; p == nil || p.Value != "abc" || p.Distance != 4 {
+		t.Errorf("Phash_distance = %+v, want abc within 4", p)
+	}
+	or := f.SceneFilter.OR
+	if or == nil || or.Rating100 == nil || or.Rating100.Value != 80 {
+		t.Fatalf("OR = %+v, want rating100 > 80", or)
+	}
+	if or.NOT == nil || or.NOT.Studios == nil || or.NOT.Studios.Value[0] != "2" {
+		t.Errorf("OR.NOT = %+v, want studio 2", or.NOT)
+	}
+	sort.Strings(f.Unsupported)
+	if len(f.Unsupported) != 2 || f.Unsupported[0] != "OR.frobnicate" || f.Unsupported[1] != "unknown" {
+		t.Errorf("Unsupported = %v, want [OR.frobnicate unknown]", f.Unsupported)
+	}
+}
+
+func TestSavedFilterToSceneFilter_invalid(t *testing.T) {
+	savedFilter := gql.SavedFilterParts{
+		Mode:          gql.FilterModeScenes,
+		Find_filter:   &gql.SavedFilterPartsFind_filterSavedFindFilterType{},
+		Object_filter: map[string]interface{}{"AND": "tags"},
+	}
+	if _, err := SavedFilterToSceneFilter(context.Background(), savedFilter); err == nil {
+		t.Error("SavedFilterToSceneFilter() error = nil, want error for a sub-filter that isn't an object")
+	}
+}
+
+func TestSavedFilterToSceneFilter_encodings(t *testing.T) {
+	objectFilter := `{
+		"rating": {"modifier": "GREATER_THAN", "value": {"value": 3}},
+		"groups": {"modifier": "INCLUDES", "value": {"items": [{"id": "7", "label": "M"}], "excluded": [{"id": "8", "label": "N"}], "depth": 0}},
+		"performers": {"modifier": "INCLUDES_ALL", "value": [{"id": 4, "label": "P"}, "5"]},
+		"studios": {"modifier": "INCLUDES", "value": {"items": [{"id": "2", "label": "B"}], "depth": -1}},
+		"interactive": {"modifier": "EQUALS", "value": true},
+		"date": {"modifier": "GREATER_THAN", "value": "2020-01-01"}
+	}`
+	savedFilter := gql.SavedFilterParts{
+		Mode:        gql.FilterModeScenes,
+		Find_filter: &gql.SavedFilterPartsFind_filterSavedFindFilterType{},
+	}
+	if err := json.Unmarshal([]byte(objectFilter), &savedFilter.Object_filter); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := SavedFilterToSceneFilter(context.Background(), savedFilter)
+	if err != nil {
+		t.Fatalf("SavedFilterToSceneFilter() error = %v", err)
+	}
+	if r := f.SceneFilter.Rating100; r == nil || r.Value != 60 || f.SceneFilter.Rating != nil {
+		t.Errorf("Rating100 = %+v, want rating 3 of 5 as rating100 > 60", r)
+	}
+	if m := f.SceneFilter.Movies; m == nil || m.Value[0] != "7" || m.Excludes[0] != "8" {
+		t.Errorf("Movies = %+v, want groups 7 excluding 8", m)
+	}
+	if p := f.SceneFilter.Performers; p == nil || len(p.Value) != 2 || p.Value[0] != "4" || p.Value[1] != "5" {
+		t.Errorf("Performers = %+v, want performers 4 and 5", p)
+	}
+	if s := f.SceneFilter.Studios; s == nil || s.Depth != -1 {
+		t.Errorf("Studios = %+v, want depth -1", s)
+	}
+	if i := f.SceneFilter.Interactive; i == nil || !*i {
+		t.Errorf("Interactive = %v, want true", i)
+	}
+	if d := f.SceneFilter.Date; d == nil || d.Value != "2020-01-01" {
+		t.Errorf("Date = %+v, want 2020-01-01", d)
+	}
+}
+
+func TestDirectionOrDefault(t *testing.T) {
+	tests := []struct {
+		sort, direction string
+		want            gql.SortDirectionEnum
+	}{
+		{"", "", gql.SortDirectionEnumAsc},
+		{"title", "", gql.SortDirectionEnumAsc},
+		{"scenes_count", "", gql.SortDirectionEnumDesc},
+		{"o_counter", "", gql.SortDirectionEnumDesc},
+		{"scenes_count", "ASC", gql.SortDirectionEnumAsc},
+		{"date", "DESC", gql.SortDirectionEnumDesc},
+	}
+	for _, tt := range tests {
+		if got := DirectionOrDefault(tt.sort, tt.direction); got != tt.want {
+			t.Errorf("DirectionOrDefault(%q, %q) = %s, want %s", tt.sort, tt.direction, got, tt.want)
+		}
+	}
+}
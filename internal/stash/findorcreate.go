@@ -5,13 +5,24 @@ import (
 	"fmt"
 	"github.com/Khan/genqlient/graphql"
 	"github.com/rs/zerolog/log"
+	"stash-vr/internal/cache"
 	"stash-vr/internal/stash/gql"
+	"time"
 )
 
+// tagIdCache holds tag ids by name, tags are looked up for every tag of every update request.
+var tagIdCache = cache.New[string, string]("tag ids", cache.Options{TTL: 10 * time.Minute})
+
 func FindOrCreateTag(ctx context.Context, client graphql.Client, name string) (string, error) {
 	if name == "" {
 		return "", fmt.Errorf("empty tag name")
 	}
+	return tagIdCache.Get(ctx, name, func(ctx context.Context) (string, error) {
+		return findOrCreateTag(ctx, client, name)
+	})
+}
+
+func findOrCreateTag(ctx context.Context, client graphql.Client, name string) (string, error) {
 	findResponse, err := gql.FindTagByName(ctx, client, name)
 	if err != nil {
 		return "", fmt.Errorf("FindTagByName '%s': %w", name, err)
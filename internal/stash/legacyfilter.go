@@ -0,0 +1,100 @@
+package stash
+
+import (
+	"context"
+	"fmt"
+	"stash-vr/internal/stash/compat"
+	"stash-vr/internal/stash/gql"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/rs/zerolog/log"
+)
+
+// Stash before v0.22 stores saved filters as a JSON string in field filter, the bundled schema doesn't know it so these
+// queries aren't generated.
+const (
+	findSavedFiltersLegacyQuery = `query FindSavedFiltersLegacy($mode: FilterMode!) {
+	findSavedFilters(mode: $mode) { id name mode filter }
+}`
+	findSavedFilterLegacyQuery = `query FindSavedFilterLegacy($id: ID!) {
+	findSavedFilter(id: $id) { id name mode filter }
+}`
+)
+
+// legacyFilterModes are the modes of saved filters that make sections, Stash before v0.22 lists saved filters by mode.
+var legacyFilterModes = []gql.FilterMode{
+	gql.FilterModeScenes,
+	gql.FilterModePerformers,
+	gql.FilterModeStudios,
+	gql.FilterModeMovies,
+	gql.FilterModeSceneMarkers,
+}
+
+type legacySavedFilter struct {
+	Id     string         `json:"id"`
+	Name   string         `json:"name"`
+	Mode   gql.FilterMode `json:"mode"`
+	Filter string         `json:"filter"`
+}
+
+func (f legacySavedFilter) toSavedFilterParts() (gql.SavedFilterParts, error) {
+	findFilter, objectFilter, err := compat.DecodeLegacyFilter(f.Filter)
+	if err != nil {
+		return gql.SavedFilterParts{}, fmt.Errorf("DecodeLegacyFilter: %w", err)
+	}
+	return gql.SavedFilterParts{
+		Id:            f.Id,
+		Name:          f.Name,
+		Mode:          f.Mode,
+		Find_filter:   findFilter,
+		Object_filter: objectFilter,
+	}, nil
+}
+
+func findSavedFiltersLegacy(ctx context.Context, client graphql.Client, modes []gql.FilterMode) ([]gql.SavedFilterParts, error) {
+	var filters []gql.SavedFilterParts
+	for _, mode := range modes {
+		var data struct {
+			FindSavedFilters []legacySavedFilter `json:"findSavedFilters"`
+		}
+		err := client.MakeRequest(ctx, &graphql.Request{
+			OpName:    "FindSavedFiltersLegacy",
+			Query:     findSavedFiltersLegacyQuery,
+			Variables: map[string]any{"mode": mode},
+		}, &graphql.Response{Data: &data})
+		if err != nil {
+			return nil, fmt.Errorf("FindSavedFiltersLegacy mode=%s: %w", mode, err)
+		}
+		for _, f := range data.FindSavedFilters {
+			savedFilter, err := f.toSavedFilterParts()
+			if err != nil {
+				log.Ctx(ctx).Warn().Err(err).Str("filterId", f.Id).Str("filterName", f.Name).Msg("Skipped filter")
+				continue
+			}
+			filters = append(filters, savedFilter)
+		}
+	}
+	return filters, nil
+}
+
+func findSavedFilterLegacy(ctx context.Context, client graphql.Client, id string) (*gql.SavedFilterParts, error) {
+	var data struct {
+		FindSavedFilter *legacySavedFilter `json:"findSavedFilter"`
+	}
+	err := client.MakeRequest(ctx, &graphql.Request{
+		OpName:    "FindSavedFilterLegacy",
+		Query:     findSavedFilterLegacyQuery,
+		Variables: map[string]any{"id": id},
+	}, &graphql.Response{Data: &data})
+	if err != nil {
+		return nil, fmt.Errorf("FindSavedFilterLegacy: %w", err)
+	}
+	if data.FindSavedFilter == nil {
+		return nil, nil
+	}
+	savedFilter, err := data.FindSavedFilter.toSavedFilterParts()
+	if err != nil {
+		return nil, err
+	}
+	return &savedFilter, nil
+}
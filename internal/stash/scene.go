@@ -0,0 +1,49 @@
+package stash
+
+import (
+	"context"
+	"stash-vr/internal/cache"
+	"stash-vr/internal/stash/gql"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// sceneCache holds full scene data for video data requests. Players tend to request the same scene repeatedly.
+var sceneCache = cache.New[string, *gql.FindSceneFullResponse]("scenes", cache.Options{TTL: time.Minute, MaxEntries: 1000})
+
+// FindSceneFull is a cached gql.FindSceneFull.
+func FindSceneFull(ctx context.Context, client graphql.Client, sceneId string) (*gql.FindSceneFullResponse, error) {
+	return sceneCache.Get(ctx, sceneId, func(ctx context.Context) (*gql.FindSceneFullResponse, error) {
+		return gql.FindSceneFull(ctx, client, sceneId)
+	})
+}
+
+// LibraryScene is a scene of FindAllSceneScans.
+type LibraryScene = gql.FindAllSceneScansFindScenesFindScenesResultTypeScenesScene
+
+// libraryCache holds the scan data of all scenes, for features working on the whole library.
+var libraryCache = cache.New[string, []*LibraryScene]("library", cache.Options{TTL: 5 * time.Minute})
+
+// FindAllSceneScans is a cached gql.FindAllSceneScans.
+func FindAllSceneScans(ctx context.Context, client graphql.Client) ([]*LibraryScene, error) {
+	return libraryCache.Get(ctx, "", func(ctx context.Context) ([]*LibraryScene, error) {
+		response, err := gql.FindAllSceneScans(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		return response.FindScenes.Scenes, nil
+	})
+}
+
+// InvalidateScene drops the cached data of a scene, call after changing it.
+func InvalidateScene(sceneId string) {
+	sceneCache.Invalidate(sceneId)
+}
+
+// InvalidateAll drops all cached Stash data, call when the library changed.
+func InvalidateAll() {
+	sceneCache.InvalidateAll()
+	tagIdCache.InvalidateAll()
+	libraryCache.InvalidateAll()
+}
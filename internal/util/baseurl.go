@@ -0,0 +1,27 @@
+package util
+
+import (
+	"net/http"
+	"stash-vr/internal/config"
+	"strings"
+)
+
+// GetBaseUrl returns the url under which Stash-VR is reachable by the client, without trailing slash.
+// PUBLIC_URL takes precedence, otherwise it's derived from the request and X-Forwarded-Host/Proto/Prefix.
+func GetBaseUrl(req *http.Request) string {
+	if publicUrl := config.Get().PublicUrl; publicUrl != "" {
+		return strings.TrimSuffix(publicUrl, "/")
+	}
+
+	host := req.Host
+	if forwardedHost := FirstHeaderValue(req, "X-Forwarded-Host"); forwardedHost != "" {
+		host = forwardedHost
+	}
+
+	prefix := strings.Trim(FirstHeaderValue(req, "X-Forwarded-Prefix"), "/")
+	if prefix != "" {
+		prefix = "/" + prefix
+	}
+
+	return GetScheme(req) + "://" + host + prefix
+}
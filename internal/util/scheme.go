@@ -3,11 +3,18 @@ package util
 import (
 	"net/http"
 	"stash-vr/internal/config"
+	"strings"
 )
 
 func GetScheme(req *http.Request) string {
-	if req.URL.Scheme == "https" || req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https" || config.Get().ForceHTTPS {
+	if req.URL.Scheme == "https" || req.TLS != nil || FirstHeaderValue(req, "X-Forwarded-Proto") == "https" || config.Get().ForceHTTPS {
 		return "https"
 	}
 	return "http"
 }
+
+// FirstHeaderValue returns the first entry of a possibly comma separated header, as set by chained proxies.
+func FirstHeaderValue(req *http.Request, key string) string {
+	v, _, _ := strings.Cut(req.Header.Get(key), ",")
+	return strings.TrimSpace(v)
+}
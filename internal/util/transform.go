@@ -8,13 +8,24 @@ import (
 type Transform[Input any, Output any] func(Input) (Output, error)
 
 func (f Transform[Input, Output]) Ordered(inputs []Input) []Output {
+	return f.OrderedLimit(inputs, 0)
+}
+
+// OrderedLimit is Ordered with at most limit inputs transformed concurrently, 0 is unlimited.
+func (f Transform[Input, Output]) OrderedLimit(inputs []Input, limit int) []Output {
 	chXs := make(chan indexed[Output], len(inputs))
+	if limit <= 0 {
+		limit = len(inputs)
+	}
+	sem := make(chan struct{}, limit)
 
 	wg := sync.WaitGroup{}
 	wg.Add(len(inputs))
 	for i, input := range inputs {
 		go func(i int, input Input) {
 			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
 			output, err := f(input)
 			if err != nil {
 				return
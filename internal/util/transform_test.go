@@ -4,7 +4,9 @@ import (
 	"errors"
 	"reflect"
 	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func nonZeroIntToString(input int) (string, error) {
@@ -40,6 +42,30 @@ func TestTransform_Ordered(t *testing.T) {
 	}
 }
 
+func TestTransform_OrderedLimit(t *testing.T) {
+	var running, maxRunning atomic.Int64
+	f := Transform[int, string](func(input int) (string, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return nonZeroIntToString(input)
+	})
+
+	got := f.OrderedLimit([]int{10, 0, 1, 2, 3, 4, 5, 6}, 2)
+	if want := []string{"10", "1", "2", "3", "4", "5", "6"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OrderedLimit() = %v, want %v", got, want)
+	}
+	if m := maxRunning.Load(); m > 2 {
+		t.Errorf("concurrent transforms = %d, want at most 2", m)
+	}
+}
+
 func BenchmarkTransform_Ordered(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		Transform[int, string](nonZeroIntToString).Ordered([]int{10, 0, 1, 2, 10, 0, 1, 2, 10, 0, 1, 2, 10, 0, 1, 2, 10, 0, 1, 2, 10, 0, 1, 2})
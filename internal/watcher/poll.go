@@ -0,0 +1,57 @@
+package watcher
+
+import (
+	"context"
+	"stash-vr/internal/stash/gql"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/rs/zerolog/log"
+)
+
+type libraryState struct {
+	count     int
+	updatedAt time.Time
+}
+
+func (s libraryState) equal(other libraryState) bool {
+	return s.count == other.count && s.updatedAt.Equal(other.updatedAt)
+}
+
+// poll compares scene count and the most recent updated_at with the previous poll.
+func poll(ctx context.Context, client graphql.Client, interval time.Duration, notify func(reason string)) {
+	var last *libraryState
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		state, err := fetchLibraryState(ctx, client)
+		if err != nil {
+			log.Ctx(ctx).Debug().Err(err).Msg("Poll failed")
+		} else {
+			if last != nil && !last.equal(state) {
+				notify("poll")
+			}
+			last = &state
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func fetchLibraryState(ctx context.Context, client graphql.Client) (libraryState, error) {
+	response, err := gql.FindLatestSceneUpdate(ctx, client)
+	if err != nil {
+		return libraryState{}, err
+	}
+	state := libraryState{count: response.FindScenes.Count}
+	if len(response.FindScenes.Scenes) > 0 {
+		state.updatedAt = response.FindScenes.Scenes[0].Updated_at
+	}
+	return state, nil
+}
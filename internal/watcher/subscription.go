@@ -0,0 +1,148 @@
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"stash-vr/internal/config"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	subIdScanComplete = "scan"
+	subIdJobs         = "jobs"
+
+	maxReconnectDelay = time.Minute
+)
+
+var subscriptions = map[string]string{
+	subIdScanComplete: `subscription{scanCompleteSubscribe}`,
+	subIdJobs:         `subscription{jobsSubscribe{type job{status description}}}`,
+}
+
+// message is the envelope of the graphql-ws (subscriptions-transport-ws) protocol.
+type message struct {
+	Id      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type jobsPayload struct {
+	Data struct {
+		JobsSubscribe struct {
+			Type string `json:"type"`
+			Job  struct {
+				Status      string `json:"status"`
+				Description string `json:"description"`
+			} `json:"job"`
+		} `json:"jobsSubscribe"`
+	} `json:"data"`
+}
+
+// subscribe keeps a websocket subscription to Stash open, reconnecting with backoff until ctx is done.
+func subscribe(ctx context.Context, notify func(reason string)) {
+	delay := time.Second
+	for {
+		start := time.Now()
+		err := subscribeOnce(ctx, notify)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(start) > maxReconnectDelay {
+			delay = time.Second
+		}
+		log.Ctx(ctx).Debug().Err(err).Dur("retry", delay).Msg("Subscription to Stash closed")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+func subscribeOnce(ctx context.Context, notify func(reason string)) error {
+	header := http.Header{}
+	if apiKey := config.Get().StashApiKey; apiKey != "" {
+		header.Add("ApiKey", apiKey)
+	}
+	dialer := websocket.Dialer{
+		HandshakeTimeout: 10 * time.Second,
+		Subprotocols:     []string{"graphql-ws"},
+	}
+	conn, _, err := dialer.DialContext(ctx, websocketUrl(config.Get().StashGraphQLUrl), header)
+	if err != nil {
+		return fmt.Errorf("dial: %w", err)
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			conn.Close()
+		}
+	}()
+
+	if err := conn.WriteJSON(message{Type: "connection_init", Payload: json.RawMessage("{}")}); err != nil {
+		return fmt.Errorf("connection_init: %w", err)
+	}
+	for id, query := range subscriptions {
+		payload, _ := json.Marshal(map[string]string{"query": query})
+		if err := conn.WriteJSON(message{Id: id, Type: "start", Payload: payload}); err != nil {
+			return fmt.Errorf("start %s: %w", id, err)
+		}
+	}
+
+	log.Ctx(ctx).Info().Msg("Subscribed to Stash events")
+
+	for {
+		var msg message
+		if err := conn.ReadJSON(&msg); err != nil {
+			return fmt.Errorf("read: %w", err)
+		}
+		switch msg.Type {
+		case "data":
+			handleData(ctx, msg, notify)
+		case "error", "connection_error":
+			return fmt.Errorf("%s: %s", msg.Type, msg.Payload)
+		case "complete":
+			return fmt.Errorf("subscription %s completed by server", msg.Id)
+		}
+	}
+}
+
+func handleData(ctx context.Context, msg message, notify func(reason string)) {
+	switch msg.Id {
+	case subIdScanComplete:
+		notify("scan complete")
+	case subIdJobs:
+		var payload jobsPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			log.Ctx(ctx).Debug().Err(err).Msg("Failed to parse job update")
+			return
+		}
+		update := payload.Data.JobsSubscribe
+		if update.Type == "REMOVE" && update.Job.Status == "FINISHED" {
+			notify("job finished: " + update.Job.Description)
+		}
+	}
+}
+
+func websocketUrl(graphqlUrl string) string {
+	if strings.HasPrefix(graphqlUrl, "https://") {
+		return "wss://" + strings.TrimPrefix(graphqlUrl, "https://")
+	}
+	return "ws://" + strings.TrimPrefix(graphqlUrl, "http://")
+}
@@ -0,0 +1,57 @@
+package watcher
+
+import (
+	"context"
+	"stash-vr/internal/config"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/rs/zerolog/log"
+)
+
+// debounce is how long to wait for further changes before acting, a library scan emits many events.
+const debounce = 5 * time.Second
+
+// Run watches Stash for library changes until ctx is done and calls onChange once per burst of changes.
+// Changes are detected through Stash's websocket subscriptions with polling of the latest scene update as fallback.
+func Run(ctx context.Context, client graphql.Client, onChange func(ctx context.Context)) {
+	ctx = log.Ctx(ctx).With().Str("mod", "watcher").Logger().WithContext(ctx)
+	chChanged := make(chan string, 1)
+
+	notify := func(reason string) {
+		select {
+		case chChanged <- reason:
+		default:
+		}
+	}
+
+	if !config.Get().IsStashEventsDisabled {
+		go subscribe(ctx, notify)
+	}
+	if interval := config.Get().StashPollIntervalSec; interval > 0 {
+		go poll(ctx, client, time.Duration(interval)*time.Second, notify)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case reason := <-chChanged:
+			log.Ctx(ctx).Debug().Str("reason", reason).Msg("Change detected in Stash")
+			timer := time.NewTimer(debounce)
+		collect:
+			for {
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-chChanged:
+				case <-timer.C:
+					break collect
+				}
+			}
+			log.Ctx(ctx).Info().Str("reason", reason).Msg("Stash library changed, rebuilding")
+			onChange(ctx)
+		}
+	}
+}